@@ -11,6 +11,7 @@ import (
 
 	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/auditlog"
 	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/common"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 // (Removed duplicate Get method)
@@ -23,7 +24,7 @@ func (r *AuditLogRepository) Search(ctx context.Context, filters map[string]stri
 // AuditLogRepository provides access to Huntress audit logs.
 // Implements repository.AuditLogRepository.
 type AuditLogRepository struct {
-	Client    *http.Client
+	Client    transport.Doer
 	BaseURL   string
 	APIKey    string
 	APISecret string
@@ -117,10 +118,13 @@ func (r *AuditLogRepository) List(ctx context.Context, params *auditlog.ListPara
 		return nil, nil, fmt.Errorf("auditlog list: decode: %w", err)
 	}
 	pagination := &common.Pagination{
-		Page:       params.Page,
-		PerPage:    params.Limit,
-		TotalItems: len(out.Data), // TODO: parse from headers if available
-		TotalPages: 1,             // TODO: parse from headers if available
+		TotalItems: len(out.Data),
+		TotalPages: 1,
 	}
+	if params != nil {
+		pagination.Page = params.Page
+		pagination.PerPage = params.Limit
+	}
+	applyPaginationHeaders(pagination, resp.Header)
 	return out.Data, pagination, nil
 }
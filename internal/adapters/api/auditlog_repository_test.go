@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/auditlog"
 )
 
 func TestAuditLogRepository_Get_Success(t *testing.T) {
@@ -26,3 +28,24 @@ func TestAuditLogRepository_Get_Success(t *testing.T) {
 		t.Fatalf("unexpected error or result: %v, %+v", err, log)
 	}
 }
+
+func TestAuditLogRepository_List_PaginationHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Page", "2")
+		w.Header().Set("X-Per-Page", "1")
+		w.Header().Set("X-Total-Pages", "4")
+		w.Header().Set("X-Total-Items", "4")
+		if _, err := w.Write([]byte(`{"data":[{"id":"2"}]}`)); err != nil {
+			t.Fatalf("error writing response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	repo := &AuditLogRepository{Client: srv.Client(), BaseURL: srv.URL}
+	logs, p, err := repo.List(context.Background(), &auditlog.ListParams{Page: 2, Limit: 1})
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("unexpected error or result: %v, %+v", err, logs)
+	}
+	if p.Page != 2 || p.PerPage != 1 || p.TotalPages != 4 || p.TotalItems != 4 {
+		t.Errorf("unexpected pagination: %+v", p)
+	}
+}
@@ -3,12 +3,13 @@ package api
 
 import (
 	"context"
-	"net/http"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 // BulkRepository provides access to Huntress bulk operations.
 type BulkRepository struct {
-	Client    *http.Client
+	Client    transport.Doer
 	BaseURL   string
 	APIKey    string
 	APISecret string
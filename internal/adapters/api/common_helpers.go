@@ -10,6 +10,9 @@ import (
 	"net/url"
 	"reflect"
 	"strconv"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/common"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 // buildQueryParams encodes struct fields with `url` tags into a query string.
@@ -44,8 +47,26 @@ func buildQueryParams(v interface{}) string {
 	return values.Encode()
 }
 
+// applyPaginationHeaders overrides p with any X-Page, X-Per-Page,
+// X-Total-Pages and X-Total-Items values present in h.
+func applyPaginationHeaders(p *common.Pagination, h http.Header) {
+	info, _ := transport.PageInfoFromHeaders(h)
+	if info.Page != 0 {
+		p.Page = info.Page
+	}
+	if info.PerPage != 0 {
+		p.PerPage = info.PerPage
+	}
+	if info.TotalPages != 0 {
+		p.TotalPages = info.TotalPages
+	}
+	if info.TotalItems != 0 {
+		p.TotalItems = info.TotalItems
+	}
+}
+
 // doGetWithQueryAndDecode performs a GET request with query params and decodes the JSON array response.
-func doGetWithQueryAndDecode(ctx context.Context, client *http.Client, baseURL, endpoint, apiKey, apiSecret string, params map[string]string) ([]map[string]interface{}, error) {
+func doGetWithQueryAndDecode(ctx context.Context, client transport.Doer, baseURL, endpoint, apiKey, apiSecret string, params map[string]string) ([]map[string]interface{}, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating GET request: %w", err)
@@ -86,7 +107,7 @@ func doGetWithQueryAndDecode(ctx context.Context, client *http.Client, baseURL,
 }
 
 // doPostBulkActionAndDecode performs a POST to a bulk endpoint and decodes the JSON object response.
-func doPostBulkActionAndDecode(ctx context.Context, client *http.Client, baseURL, endpoint, apiKey, apiSecret, idsKey string, ids []string, payload interface{}) (map[string]interface{}, error) {
+func doPostBulkActionAndDecode(ctx context.Context, client transport.Doer, baseURL, endpoint, apiKey, apiSecret, idsKey string, ids []string, payload interface{}) (map[string]interface{}, error) {
 	reqBody := map[string]interface{}{
 		idsKey: ids,
 		"data": payload,
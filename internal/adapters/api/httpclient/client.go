@@ -8,74 +8,27 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"time"
-)
 
-// Retrier defines the interface for retry logic in HTTP clients.
-type Retrier interface {
-	MaxRetries() int
-	IsRetryableStatusCode(int) bool
-	CalculateBackoff(int) time.Duration
-}
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
+)
 
-// Client is a reusable HTTP client with timeout, context, rate limiting, and retry support.
+// Client is a thin wrapper over a transport.Doer for API adapters. Rate
+// limiting and retries are the Doer's job; pass the shared pipeline from
+// pkg/huntress to get the client's policies.
 type Client struct {
-	HTTPClient  *http.Client
-	Timeout     time.Duration
-	RateLimiter *RateLimiter
-	Retrier     Retrier
+	Transport transport.Doer
 }
 
-// New creates a new HTTP client with the given timeout, rate limiter, and retrier.
-// New creates a new HTTP client with the given timeout, rate limiter, and retrier.
-// NOTE: Replace 'interface{}' with the actual Retrier type when available.
-func New(timeout time.Duration, rateLimiter *RateLimiter, retrier Retrier) *Client {
-	return &Client{
-		HTTPClient:  &http.Client{Timeout: timeout},
-		Timeout:     timeout,
-		RateLimiter: rateLimiter,
-		Retrier:     retrier,
-	}
+// New creates a Client that sends requests through d.
+func New(d transport.Doer) *Client {
+	return &Client{Transport: d}
 }
 
-// Do executes an HTTP request with context, rate limiting, and retries.
+// Do sends req through the Transport with ctx.
 func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
-	// Rate limiting
-	if c.RateLimiter != nil {
-		if err := c.RateLimiter.Wait(ctx); err != nil {
-			return nil, &RateLimitError{Message: "API rate limit exceeded"}
-		}
-	}
-
-	var resp *http.Response
-	var err error
-	maxRetries := 0
-	if c.Retrier != nil {
-		maxRetries = c.Retrier.MaxRetries()
-	}
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if ctx.Err() != nil {
-			// Always wrap with %w so errors.Is works
-			return nil, fmt.Errorf("httpclient: context error: %w", ctx.Err())
-		}
-		resp, err = c.HTTPClient.Do(req.WithContext(ctx))
-		if err == nil && resp != nil && (resp.StatusCode < 500 || resp.StatusCode > 599) && resp.StatusCode != 429 {
-			return resp, nil
-		}
-		// Only retry on retryable status codes (5xx, 429)
-		// If resp is nil, do not retry (network or context error)
-		if resp == nil || c.Retrier == nil || !c.Retrier.IsRetryableStatusCode(resp.StatusCode) {
-			break
-		}
-		delay := c.Retrier.CalculateBackoff(attempt)
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("httpclient: context error: %w", ctx.Err())
-		case <-time.After(delay):
-		}
-	}
+	resp, err := c.Transport.Do(req.WithContext(ctx))
 	if err != nil {
-		return resp, fmt.Errorf("httpclient: http client do: %w", err)
+		return resp, fmt.Errorf("httpclient: do: %w", err)
 	}
 	return resp, nil
 }
@@ -111,18 +64,3 @@ func decodeJSON(r io.Reader, v interface{}) error {
 	}
 	return nil
 }
-
-// RateLimitError is returned when the API rate limit is exceeded.
-type RateLimitError struct {
-	Message string
-}
-
-func (e *RateLimitError) Error() string {
-	return e.Message
-}
-
-// Is allows errors.Is(err, &RateLimitError{}) to work for type checks.
-func (e *RateLimitError) Is(target error) bool {
-	_, ok := target.(*RateLimitError)
-	return ok
-}
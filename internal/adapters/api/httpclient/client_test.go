@@ -4,21 +4,15 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
-)
-
-type mockRetrier struct {
-	maxRetries  int
-	shouldRetry bool
-	backoff     time.Duration
-}
 
-func (m *mockRetrier) MaxRetries() int                      { return m.maxRetries }
-func (m *mockRetrier) IsRetryableStatusCode(_ int) bool     { return m.shouldRetry }
-func (m *mockRetrier) CalculateBackoff(_ int) time.Duration { return m.backoff }
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
+)
 
 func TestClient_Do_Success(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -28,7 +22,7 @@ func TestClient_Do_Success(t *testing.T) {
 		}
 	}))
 	defer srv.Close()
-	client := New(2*time.Second, nil, nil)
+	client := New(srv.Client())
 	req, _ := http.NewRequestWithContext(context.Background(), "GET", srv.URL, nil)
 	resp, err := client.Do(context.Background(), req)
 	if err != nil {
@@ -44,54 +38,22 @@ func TestClient_Do_Success(t *testing.T) {
 	}
 }
 
-func TestClient_Do_RateLimit(t *testing.T) {
-	r := NewRateLimiter(0, time.Second) // always rate limited
-	client := New(2*time.Second, r, nil)
-	req, _ := http.NewRequestWithContext(context.Background(), "GET", "http://example.com", nil)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
-	defer cancel()
-	resp, err := client.Do(ctx, req)
-	if resp != nil && resp.Body != nil {
-		if cerr := resp.Body.Close(); cerr != nil {
-			t.Errorf("error closing response body: %v", cerr)
-		}
-	}
-	if err == nil {
-		t.Errorf("expected error due to rate limiting, got nil")
-	}
-	if !errors.Is(err, &RateLimitError{}) && !errors.Is(err, context.DeadlineExceeded) {
-		t.Errorf("expected RateLimitError or context.DeadlineExceeded, got %v", err)
-	}
-}
-
-func TestClient_Do_Retry(t *testing.T) {
-	attempts := 0
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		attempts++
-		if attempts < 3 {
-			w.WriteHeader(500)
-		} else {
-			w.WriteHeader(200)
-		}
+func TestClient_Do_Transport(t *testing.T) {
+	errLimited := errors.New("rate limited")
+	var got *http.Request
+	client := New(transport.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader(""))}, errLimited
 	}))
-	defer srv.Close()
-	retrier := &mockRetrier{maxRetries: 2, shouldRetry: true, backoff: 10 * time.Millisecond}
-	client := New(2*time.Second, nil, retrier)
-	req, _ := http.NewRequestWithContext(context.Background(), "GET", srv.URL, nil)
-	resp, err := client.Do(context.Background(), req)
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
-	}
-	if resp != nil && resp.Body != nil {
-		if err := resp.Body.Close(); err != nil {
-			t.Errorf("error closing response body: %v", err)
-		}
-	}
-	if resp.StatusCode != 200 {
-		t.Errorf("expected 200, got %d", resp.StatusCode)
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", "http://api.invalid/agents", nil)
+	resp, err := client.Do(ctx, req)
+	if !errors.Is(err, errLimited) || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Do = %v, %v; want the transport's response and error", resp, err)
 	}
-	if attempts != 3 {
-		t.Errorf("expected 3 attempts, got %d", attempts)
+	if got == nil || got.Context().Value(ctxKey{}) != "v" {
+		t.Error("expected the request to reach the transport with the caller's context")
 	}
 }
 
@@ -101,8 +63,7 @@ func TestClient_Do_ContextCancel(t *testing.T) {
 		w.WriteHeader(200)
 	}))
 	defer srv.Close()
-	retrier := &mockRetrier{maxRetries: 2, shouldRetry: true, backoff: 50 * time.Millisecond}
-	client := New(2*time.Second, nil, retrier)
+	client := New(srv.Client())
 	req, _ := http.NewRequestWithContext(context.Background(), "GET", srv.URL, nil)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
 	defer cancel()
@@ -125,7 +86,7 @@ func TestClient_DoJSON_Success(t *testing.T) {
 		}
 	}))
 	defer srv.Close()
-	client := New(2*time.Second, nil, nil)
+	client := New(srv.Client())
 	req, _ := http.NewRequestWithContext(context.Background(), "GET", srv.URL, nil)
 	var v map[string]string
 	resp, err := client.DoJSON(context.Background(), req, &v)
@@ -153,7 +114,7 @@ func TestClient_DoJSON_ErrorStatus(t *testing.T) {
 		}
 	}))
 	defer srv.Close()
-	client := New(2*time.Second, nil, nil)
+	client := New(srv.Client())
 	req, _ := http.NewRequestWithContext(context.Background(), "GET", srv.URL, nil)
 	var v map[string]string
 	resp, err := client.DoJSON(context.Background(), req, &v)
@@ -175,10 +136,3 @@ func Test_decodeJSON_Error(t *testing.T) {
 		t.Error("expected error decoding bad json")
 	}
 }
-
-func TestRateLimitError_Error(t *testing.T) {
-	err := &RateLimitError{Message: "foo"}
-	if err.Error() != "foo" {
-		t.Errorf("expected 'foo', got '%s'", err.Error())
-	}
-}
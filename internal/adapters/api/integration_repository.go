@@ -7,11 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 // IntegrationRepository provides access to Huntress integrations.
 type IntegrationRepository struct {
-	Client    *http.Client
+	Client    transport.Doer
 	BaseURL   string
 	APIKey    string
 	APISecret string
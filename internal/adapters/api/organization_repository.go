@@ -9,28 +9,37 @@ import (
 	"os"
 	"time"
 
-	// Correct the import path for the HTTP client
 	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/organization"
 	httpClient "github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 	"github.com/greysquirr3l/bishoujo-huntress/internal/ports/repository"
 )
 
 // OrganizationRepository implements the repository.OrganizationRepository interface
 type OrganizationRepository struct {
-	// Use the imported http client type
-	httpClient *httpClient.Client
-	baseURL    string
+	client  transport.Doer
+	baseURL string
 }
 
-// NewOrganizationRepository creates a new OrganizationRepository instance
-// Update the function signature to use the correct client type
-func NewOrganizationRepository(client *httpClient.Client, baseURL string) *OrganizationRepository {
+// NewOrganizationRepository creates a new OrganizationRepository that sends
+// requests through client, normally the shared pipeline from pkg/huntress
+func NewOrganizationRepository(client transport.Doer, baseURL string) *OrganizationRepository {
 	return &OrganizationRepository{
-		httpClient: client,
-		baseURL:    baseURL,
+		client:  client,
+		baseURL: baseURL,
 	}
 }
 
+// do sends a request relative to the base URL through the repository's
+// Doer, decoding the JSON response into result
+func (r *OrganizationRepository) do(ctx context.Context, method, path string, body, result interface{}, opts *httpClient.RequestOptions) (*http.Response, error) {
+	hc, err := httpClient.NewClient(r.baseURL, "", "", httpClient.WithTransport(r.client))
+	if err != nil {
+		return nil, err
+	}
+	return hc.Do(ctx, method, path, body, result, opts)
+}
+
 // Get retrieves a specific organization by its ID
 func (r *OrganizationRepository) Get(ctx context.Context, id string) (*organization.Organization, error) {
 	// Construct the full URL using the base URL and path
@@ -38,8 +47,7 @@ func (r *OrganizationRepository) Get(ctx context.Context, id string) (*organizat
 	// endpoint := fmt.Sprintf("%s%s", r.baseURL, path) // BaseURL is handled by the client's Do method
 
 	var orgDTO organizationDTO
-	// Use the client's Do method for the request
-	resp, err := r.httpClient.Do(ctx, http.MethodGet, path, nil, &orgDTO, nil) // Pass nil for RequestOptions if none
+	resp, err := r.do(ctx, http.MethodGet, path, nil, &orgDTO, nil) // Pass nil for RequestOptions if none
 	if err != nil {
 		return nil, fmt.Errorf("failed to get organization: %w", err)
 	}
@@ -113,7 +121,7 @@ func (r *OrganizationRepository) List(ctx context.Context, params *organization.
 	}
 
 	// Use the client's Do method
-	resp, err := r.httpClient.Do(ctx, http.MethodGet, path, nil, &response, reqOpts)
+	resp, err := r.do(ctx, http.MethodGet, path, nil, &response, reqOpts)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to list organizations: %w", err)
 	}
@@ -166,7 +174,7 @@ func (r *OrganizationRepository) Create(ctx context.Context, org *organization.O
 
 	var responseDTO organizationDTO
 	// Use the client's Do method
-	resp, err := r.httpClient.Do(ctx, http.MethodPost, path, dto, &responseDTO, nil)
+	resp, err := r.do(ctx, http.MethodPost, path, dto, &responseDTO, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create organization: %w", err)
 	}
@@ -209,7 +217,7 @@ func (r *OrganizationRepository) Update(ctx context.Context, org *organization.O
 
 	var responseDTO organizationDTO
 	// Use PUT or PATCH depending on API design (PUT usually replaces, PATCH updates)
-	resp, err := r.httpClient.Do(ctx, http.MethodPut, path, dto, &responseDTO, nil)
+	resp, err := r.do(ctx, http.MethodPut, path, dto, &responseDTO, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update organization: %w", err)
 	}
@@ -238,7 +246,7 @@ func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
 	path := fmt.Sprintf("/organizations/%s", url.PathEscape(id))
 
 	// Use the client's Do method, expecting no response body on success (nil for result)
-	resp, err := r.httpClient.Do(ctx, http.MethodDelete, path, nil, nil, nil)
+	resp, err := r.do(ctx, http.MethodDelete, path, nil, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to delete organization: %w", err)
 	}
@@ -268,7 +276,7 @@ func (r *OrganizationRepository) GetUsers(ctx context.Context, organizationID st
 	}
 
 	// Use the client's Do method
-	resp, err := r.httpClient.Do(ctx, http.MethodGet, path, nil, &response, nil)
+	resp, err := r.do(ctx, http.MethodGet, path, nil, &response, nil)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get organization users: %w", err)
 	}
@@ -308,7 +316,7 @@ func (r *OrganizationRepository) AddUser(ctx context.Context, organizationID str
 	dto := r.convertUserToDTO(user)
 
 	// Use the client's Do method, potentially expecting a user DTO or no body on success
-	resp, err := r.httpClient.Do(ctx, http.MethodPost, path, dto, nil, nil) // Assuming no response body needed
+	resp, err := r.do(ctx, http.MethodPost, path, dto, nil, nil) // Assuming no response body needed
 	if err != nil {
 		return fmt.Errorf("failed to add user to organization: %w", err)
 	}
@@ -332,7 +340,7 @@ func (r *OrganizationRepository) RemoveUser(ctx context.Context, organizationID
 	path := fmt.Sprintf("/organizations/%s/users/%s", url.PathEscape(organizationID), url.PathEscape(userID))
 
 	// Use the client's Do method, expecting no response body on success
-	resp, err := r.httpClient.Do(ctx, http.MethodDelete, path, nil, nil, nil)
+	resp, err := r.do(ctx, http.MethodDelete, path, nil, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to remove user from organization: %w", err)
 	}
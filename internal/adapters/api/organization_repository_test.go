@@ -8,8 +8,6 @@ import (
 	"net/http"
 	"strings"
 	"testing"
-
-	httpClient "github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http"
 )
 
 // Use roundTripFunc from client_test.go (do not redeclare here)
@@ -20,9 +18,7 @@ func TestOrganizationRepository_List_HTTPError(t *testing.T) {
 			return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader("fail"))}
 		}),
 	}
-	hc, _ := httpClient.NewClient("http://x", "k", "s")
-	hc.HTTPClient = stdClient
-	repo := &OrganizationRepository{httpClient: hc, baseURL: "http://x"}
+	repo := NewOrganizationRepository(stdClient, "http://x")
 	ctx := context.Background()
 	_, _, err := repo.List(ctx, nil)
 	if err == nil {
@@ -36,9 +32,7 @@ func TestOrganizationRepository_List_BadJSON(t *testing.T) {
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("notjson"))}
 		}),
 	}
-	hc, _ := httpClient.NewClient("http://x", "k", "s")
-	hc.HTTPClient = stdClient
-	repo := &OrganizationRepository{httpClient: hc, baseURL: "http://x"}
+	repo := NewOrganizationRepository(stdClient, "http://x")
 	ctx := context.Background()
 	_, _, err := repo.List(ctx, nil)
 	if err == nil {
@@ -52,9 +46,7 @@ func TestOrganizationRepository_Delete_HTTPError(t *testing.T) {
 			return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader("not found"))}
 		}),
 	}
-	hc, _ := httpClient.NewClient("http://x", "k", "s")
-	hc.HTTPClient = stdClient
-	repo := &OrganizationRepository{httpClient: hc, baseURL: "http://x"}
+	repo := NewOrganizationRepository(stdClient, "http://x")
 	ctx := context.Background()
 	err := repo.Delete(ctx, "org1")
 	if err == nil {
@@ -68,9 +60,7 @@ func TestOrganizationRepository_Delete_Success(t *testing.T) {
 			return &http.Response{StatusCode: 204, Body: io.NopCloser(strings.NewReader(""))}
 		}),
 	}
-	hc, _ := httpClient.NewClient("http://x", "k", "s")
-	hc.HTTPClient = stdClient
-	repo := &OrganizationRepository{httpClient: hc, baseURL: "http://x"}
+	repo := NewOrganizationRepository(stdClient, "http://x")
 	ctx := context.Background()
 	err := repo.Delete(ctx, "org1")
 	if err != nil {
@@ -89,9 +79,7 @@ func TestOrganizationRepository_Get_Success(t *testing.T) {
 			}
 		}),
 	}
-	hc, _ := httpClient.NewClient("http://x", "k", "s")
-	hc.HTTPClient = stdClient
-	repo := &OrganizationRepository{httpClient: hc, baseURL: "http://x"}
+	repo := NewOrganizationRepository(stdClient, "http://x")
 	ctx := context.Background()
 	got, err := repo.Get(ctx, "org1")
 	if err != nil {
@@ -108,9 +96,7 @@ func TestOrganizationRepository_Get_HTTPError(t *testing.T) {
 			return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader("not found"))}
 		}),
 	}
-	hc, _ := httpClient.NewClient("http://x", "k", "s")
-	hc.HTTPClient = stdClient
-	repo := &OrganizationRepository{httpClient: hc, baseURL: "http://x"}
+	repo := NewOrganizationRepository(stdClient, "http://x")
 	ctx := context.Background()
 	_, err := repo.Get(ctx, "org1")
 	if err == nil || !strings.Contains(err.Error(), "API error") {
@@ -124,9 +110,7 @@ func TestOrganizationRepository_Get_BadJSON(t *testing.T) {
 			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("notjson"))}
 		}),
 	}
-	hc, _ := httpClient.NewClient("http://x", "k", "s")
-	hc.HTTPClient = stdClient
-	repo := &OrganizationRepository{httpClient: hc, baseURL: "http://x"}
+	repo := NewOrganizationRepository(stdClient, "http://x")
 	ctx := context.Background()
 	_, err := repo.Get(ctx, "org1")
 	if err == nil || !strings.Contains(err.Error(), "parsing response body") {
@@ -9,13 +9,14 @@ import (
 	"net/http"
 
 	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/webhook"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 // WebhookRepository provides CRUD operations for Huntress webhooks.
 //
 // NOTE: Only one definition of WebhookRepository is allowed in this file.
 type WebhookRepository struct {
-	Client    *http.Client
+	Client    transport.Doer
 	BaseURL   string
 	APIKey    string
 	APISecret string
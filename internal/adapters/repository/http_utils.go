@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/errors"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 	"github.com/greysquirr3l/bishoujo-huntress/internal/ports/repository"
 )
 
@@ -51,10 +52,9 @@ func buildQueryParams(filters map[string]interface{}) url.Values {
 	return query
 }
 
-// HTTPClient defines the interface for an HTTP client
-type HTTPClient interface {
-	Do(req *http.Request) (*http.Response, error)
-}
+// HTTPClient defines the interface for an HTTP client. Repositories built by
+// the public client receive its shared request pipeline.
+type HTTPClient = transport.Doer
 
 // createRequest creates an HTTP request with the provided parameters
 func createRequest(ctx context.Context, method, url string, body []byte, headers map[string]string) (*http.Request, error) {
@@ -130,32 +130,18 @@ func extractPagination(headers http.Header) repository.Pagination {
 		TotalItems: 0,
 	}
 
-	// Parse page number
-	if page := headers.Get("X-Page"); page != "" {
-		if val, err := strconv.Atoi(page); err == nil {
-			pagination.Page = val
-		}
+	info, _ := transport.PageInfoFromHeaders(headers)
+	if info.Page != 0 {
+		pagination.Page = info.Page
 	}
-
-	// Parse per page
-	if perPage := headers.Get("X-Per-Page"); perPage != "" {
-		if val, err := strconv.Atoi(perPage); err == nil {
-			pagination.PerPage = val
-		}
+	if info.PerPage != 0 {
+		pagination.PerPage = info.PerPage
 	}
-
-	// Parse total pages
-	if totalPages := headers.Get("X-Total-Pages"); totalPages != "" {
-		if val, err := strconv.Atoi(totalPages); err == nil {
-			pagination.TotalPages = val
-		}
+	if info.TotalPages != 0 {
+		pagination.TotalPages = info.TotalPages
 	}
-
-	// Parse total items
-	if totalItems := headers.Get("X-Total-Count"); totalItems != "" {
-		if val, err := strconv.Atoi(totalItems); err == nil {
-			pagination.TotalItems = val
-		}
+	if info.TotalItems != 0 {
+		pagination.TotalItems = info.TotalItems
 	}
 
 	return pagination
@@ -0,0 +1,33 @@
+// Package filelock provides advisory file locks and process liveness checks
+// used to coordinate state shared between processes on one host.
+package filelock
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// pollInterval is how often a contended lock is retried.
+const pollInterval = 5 * time.Millisecond
+
+// Lock acquires an exclusive lock associated with path, blocking until it
+// is available or ctx is done. The returned function releases the lock.
+// Locks held by a process that exits are released by the operating system
+// on Unix; elsewhere a lock left for 30 seconds is broken.
+func Lock(ctx context.Context, path string) (func() error, error) {
+	for {
+		unlock, ok, err := tryLock(path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return unlock, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for lock on %s: %w", path, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !unix
+
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleAfter is how old a lock file must be before it is assumed to have
+// been left behind by a crashed process.
+const staleAfter = 30 * time.Second
+
+// tryLock creates path+".lock" exclusively, breaking it if it is stale.
+func tryLock(path string) (func() error, bool, error) {
+	lockPath := path + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 -- path is chosen by the caller
+	if err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			return nil, false, fmt.Errorf("creating lock file: %w", err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleAfter {
+			_ = os.Remove(lockPath)
+		}
+		return nil, false, nil
+	}
+	_ = f.Close()
+	return func() error { return os.Remove(lockPath) }, true, nil
+}
+
+// ProcessAlive reports whether a process with the given pid exists. Without
+// a portable check it assumes every positive pid is alive.
+func ProcessAlive(pid int) bool {
+	return pid > 0
+}
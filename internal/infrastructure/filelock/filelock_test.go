@@ -0,0 +1,44 @@
+package filelock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock_Exclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	unlock, err := Lock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Lock(ctx, path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a second lock to block, got %v", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	unlock, err = Lock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("expected the lock after release, got %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+}
+
+func TestProcessAlive(t *testing.T) {
+	if !ProcessAlive(os.Getpid()) {
+		t.Error("expected the current process to be alive")
+	}
+	if ProcessAlive(0) {
+		t.Error("expected pid 0 to be reported as not alive")
+	}
+}
@@ -11,10 +11,16 @@ import (
 	"net/url"
 	"time"
 
-	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
+	"github.com/google/uuid"
+
 	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
+// RequestIDHeader is the header that carries a request's ID. Requests get a
+// random UUID unless RequestOptions.Headers sets one.
+const RequestIDHeader = "X-Request-ID"
+
 // APIError represents an error returned by the Huntress API
 type APIError struct {
 	StatusCode int
@@ -31,14 +37,16 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error: %d - %s", e.StatusCode, e.Message)
 }
 
-// Client is an HTTP client for the Huntress API
+// Client is a thin wrapper over a transport.Doer: it builds JSON requests
+// against BaseURL and decodes the responses. Retries, rate limiting and the
+// rest of the request policy belong to the Doer, normally the shared
+// pipeline built by pkg/huntress.
 type Client struct {
-	BaseURL     *url.URL
-	HTTPClient  *http.Client
-	APIKey      string
-	APISecret   string
-	UserAgent   string
-	RetryConfig *retry.Config
+	BaseURL   *url.URL
+	Transport transport.Doer
+	APIKey    string
+	APISecret string
+	UserAgent string
 }
 
 // NewClient creates a new HTTP client
@@ -50,13 +58,12 @@ func NewClient(baseURL string, apiKey string, apiSecret string, opts ...ClientOp
 
 	client := &Client{
 		BaseURL: parsedURL,
-		HTTPClient: &http.Client{
+		Transport: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		APIKey:      apiKey,
-		APISecret:   apiSecret,
-		UserAgent:   "Bishoujo-Huntress/0.1.0",
-		RetryConfig: &retry.DefaultConfig,
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+		UserAgent: "Bishoujo-Huntress/0.1.0",
 	}
 
 	// Apply all client options
@@ -70,10 +77,19 @@ func NewClient(baseURL string, apiKey string, apiSecret string, opts ...ClientOp
 // ClientOption is a function that configures a Client
 type ClientOption func(*Client)
 
-// WithHTTPClient sets the HTTP client to use
+// WithHTTPClient sends requests with httpClient directly, without the
+// shared pipeline
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
-		c.HTTPClient = httpClient
+		c.Transport = httpClient
+	}
+}
+
+// WithTransport sends requests through d, typically the shared pipeline of
+// a pkg/huntress client
+func WithTransport(d transport.Doer) ClientOption {
+	return func(c *Client) {
+		c.Transport = d
 	}
 }
 
@@ -90,13 +106,6 @@ func WithPerRequestUserAgent() ClientOption {
 	return func(_ *Client) {}
 }
 
-// WithRetryConfig sets the retry configuration
-func WithRetryConfig(retryConfig *retry.Config) ClientOption {
-	return func(c *Client) {
-		c.RetryConfig = retryConfig
-	}
-}
-
 // RequestOptions represents options for a request
 type RequestOptions struct {
 	Headers map[string]string
@@ -139,6 +148,7 @@ func (c *Client) Do(ctx context.Context, method, path string, body, result inter
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set(RequestIDHeader, uuid.NewString())
 
 	// Set Basic Auth
 	if c.APIKey != "" && c.APISecret != "" {
@@ -163,19 +173,14 @@ func (c *Client) Do(ctx context.Context, method, path string, body, result inter
 		req.URL.RawQuery = q.Encode()
 	}
 
-	// Use Retrier.Do to retry on both errors and retryable status codes
-	retrier := retry.NewRetrier(*c.RetryConfig)
-	var resp *http.Response
-	resp, err = retrier.Do(ctx, func() (*http.Response, error) {
-		return c.HTTPClient.Do(req)
-	})
+	resp, err := c.Transport.Do(req)
 	if err != nil {
 		if resp != nil {
-			if closeErr := resp.Body.Close(); closeErr != nil {
-				logging.GetLogger().Error("failed to close response body", logging.Field{Key: "error", Value: closeErr})
-			}
+			// The shared pipeline reports non-2xx responses as typed
+			// errors with the body buffered; pass them through unchanged
+			return resp, err
 		}
-		return nil, fmt.Errorf("request failed after retries: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
 	// Read the response body
@@ -197,6 +202,10 @@ func (c *Client) Do(ctx context.Context, method, path string, body, result inter
 			RawBody:    respBody,
 			RequestID:  resp.Header.Get("X-Request-Id"),
 		}
+		if apiErr.RequestID == "" {
+			// The API did not report an ID; fall back to the one we sent
+			apiErr.RequestID = req.Header.Get(RequestIDHeader)
+		}
 
 		// Try to parse error message from response
 		var errResp map[string]interface{}
@@ -224,23 +233,20 @@ func (c *Client) Do(ctx context.Context, method, path string, body, result inter
 	return resp, nil
 }
 
-// GetPagination extracts pagination information from an HTTP response
+// GetPagination extracts pagination information from an HTTP response's
+// X-Page, X-Per-Page, X-Total-Pages and X-Total-Items headers. Without
+// them the response is reported as a single page.
 func GetPagination(resp *http.Response) (*Pagination, error) {
 	if resp == nil {
 		return nil, fmt.Errorf("response is nil")
 	}
-
-	// Extract pagination from headers if available
-	pagination := &Pagination{}
-
-	// If headers don't contain pagination info, return default values
-	// This is a simplification - you'll need to adjust based on Huntress API's
-	// actual pagination implementation
-	pagination.CurrentPage = 1
-	pagination.TotalPages = 1
-	pagination.TotalItems = 0
-	pagination.ItemsPerPage = 0
-
+	pagination := &Pagination{CurrentPage: 1, TotalPages: 1}
+	if info, ok := transport.PageInfoFromHeaders(resp.Header); ok {
+		pagination.CurrentPage = max(info.Page, 1)
+		pagination.TotalPages = max(info.TotalPages, 1)
+		pagination.TotalItems = info.TotalItems
+		pagination.ItemsPerPage = info.PerPage
+	}
 	return pagination, nil
 }
 
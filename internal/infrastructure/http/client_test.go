@@ -3,18 +3,21 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 func newTestClient(handler http.Handler) *Client {
 	ts := httptest.NewServer(handler)
 	client, _ := NewClient(ts.URL, "test-key", "test-secret")
-	client.HTTPClient = ts.Client()
+	client.Transport = ts.Client()
 	return client
 }
 
@@ -126,43 +129,28 @@ func TestClient_Do_AuthHeader(t *testing.T) {
 	}
 }
 
-func TestClient_Do_RetryLogic(t *testing.T) {
-	calls := 0
-	client := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+func TestClient_Do_Transport(t *testing.T) {
+	errNotFound := errors.New("not found")
+	var calls int
+	client, _ := NewClient("http://api.invalid/v1/", "k", "s", WithTransport(transport.DoerFunc(func(req *http.Request) (*http.Response, error) {
 		calls++
-		if calls < 3 {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			if _, err := w.Write([]byte(`{"message":"unavailable"}`)); err != nil {
-				t.Errorf("error writing response: %v", err)
-			}
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte(`{"ok":true}`)); err != nil {
-			t.Errorf("error writing response: %v", err)
+		if req.URL.Path == "/v1/missing" {
+			resp := &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{}`))}
+			return resp, fmt.Errorf("pipeline: %w", errNotFound)
 		}
-	}))
-	client.RetryConfig = &retry.Config{
-		MaxRetries:           2,
-		BaseDelay:            10 * time.Millisecond,
-		MaxDelay:             50 * time.Millisecond,
-		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
-	}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+	})))
+
 	var result map[string]interface{}
-	resp, err := client.Do(context.Background(), http.MethodGet, "/retry", nil, &result, nil)
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
-	}
-	if resp != nil && resp.Body != nil {
-		if err := resp.Body.Close(); err != nil {
-			t.Errorf("error closing response body: %v", err)
-		}
+	if _, err := client.Get(context.Background(), "ok", &result, nil); err != nil || result["ok"] != true {
+		t.Fatalf("Get = %v, %v; want the decoded body", result, err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("expected 200, got %d", resp.StatusCode)
+	resp, err := client.Get(context.Background(), "missing", nil, nil)
+	if !errors.Is(err, errNotFound) || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Get = %v, %v; want the transport's error and response unchanged", resp, err)
 	}
-	if calls != 3 {
-		t.Errorf("expected 3 calls, got %d", calls)
+	if calls != 2 {
+		t.Errorf("expected one transport call per request, got %d", calls)
 	}
 }
 
@@ -222,3 +210,19 @@ func TestGetPagination_Defaults(t *testing.T) {
 		t.Errorf("expected default pagination, got %+v", pagination)
 	}
 }
+
+func TestGetPagination_Headers(t *testing.T) {
+	resp := &http.Response{Header: http.Header{
+		"X-Page":        {"3"},
+		"X-Per-Page":    {"25"},
+		"X-Total-Pages": {"4"},
+		"X-Total-Items": {"90"},
+	}}
+	pagination, err := GetPagination(resp)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := (Pagination{CurrentPage: 3, TotalPages: 4, TotalItems: 90, ItemsPerPage: 25}); *pagination != want {
+		t.Errorf("expected %+v, got %+v", want, *pagination)
+	}
+}
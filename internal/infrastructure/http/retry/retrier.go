@@ -4,7 +4,9 @@ package retry
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
+	"math/rand/v2"
 	"net/http"
 	"time"
 )
@@ -13,12 +15,17 @@ import (
 type Config struct {
 	// MaxRetries is the maximum number of retries
 	MaxRetries int
-	// MaxDelay is the maximum delay between retries
+	// MaxDelay is the maximum delay between retries. A server that asks for
+	// a longer wait with Retry-After or X-RateLimit-Reset is not retried;
+	// its response is returned at once.
 	MaxDelay time.Duration
 	// BaseDelay is the base delay for the exponential backoff
 	BaseDelay time.Duration
 	// RetryableStatusCodes is a list of HTTP status codes that should trigger a retry
 	RetryableStatusCodes []int
+	// MaxElapsedTime caps the total time spent across all attempts, including
+	// backoff delays. Zero means no cap.
+	MaxElapsedTime time.Duration
 }
 
 // DefaultConfig provides sensible defaults for the retry configuration
@@ -49,10 +56,14 @@ func NewRetrier(config Config) *Retrier {
 
 // Do executes the given function with retry logic
 // It will retry the function if it returns an error or if the response status code
-// is in the list of retryable status codes
+// is in the list of retryable status codes. When the server supplies a Retry-After
+// or X-RateLimit-Reset header, that delay is used instead of the computed backoff,
+// unless it exceeds MaxDelay or the MaxElapsedTime budget, in which case the
+// response is returned without waiting.
 func (r *Retrier) Do(ctx context.Context, fn func() (*http.Response, error)) (*http.Response, error) {
 	var resp *http.Response
 	var err error
+	start := time.Now()
 
 	for attempt := 0; attempt <= r.config.MaxRetries; attempt++ {
 		// Check if context is done before making the request
@@ -75,8 +86,26 @@ func (r *Retrier) Do(ctx context.Context, fn func() (*http.Response, error)) (*h
 			return resp, err
 		}
 
-		// Calculate backoff delay using exponential backoff with jitter
+		// Calculate backoff delay using exponential backoff with jitter,
+		// deferring to the server when it tells us how long to wait
 		delay := r.calculateBackoff(attempt)
+		if wait, ok := RetryAfter(resp, time.Now()); ok {
+			// A server-imposed wait is never shortened, so rather than
+			// block for longer than MaxDelay, hand the response back and
+			// let the caller see the rate limit
+			if r.config.MaxDelay > 0 && wait > r.config.MaxDelay {
+				return resp, err
+			}
+			delay = wait
+		}
+
+		// Give up if waiting would exceed the overall time budget
+		if r.config.MaxElapsedTime > 0 && time.Since(start)+delay > r.config.MaxElapsedTime {
+			return resp, err
+		}
+
+		// The response is about to be discarded, so release its connection
+		drainAndClose(resp)
 
 		// Create a timer for the backoff delay
 		timer := time.NewTimer(delay)
@@ -85,7 +114,7 @@ func (r *Retrier) Do(ctx context.Context, fn func() (*http.Response, error)) (*h
 		select {
 		case <-ctx.Done():
 			timer.Stop()
-			return resp, fmt.Errorf("context error: %w", ctx.Err())
+			return nil, fmt.Errorf("context error: %w", ctx.Err())
 		case <-timer.C:
 			// Continue to the next attempt
 		}
@@ -131,21 +160,26 @@ func ExecuteWithRetry(ctx context.Context, fn func() error, cfg *Config) error {
 // calculateBackoff computes the backoff delay for a given retry attempt (stateless version for ExecuteWithRetry)
 func calculateBackoff(cfg *Config, attempt int) time.Duration {
 	backoff := float64(cfg.BaseDelay) * math.Pow(2, float64(attempt))
-	jitter := time.Duration(backoff / 2 * (1 - (0.5 * math.Sin(float64(attempt)))))
-	delay := time.Duration(backoff) + jitter
-	if delay > cfg.MaxDelay {
-		delay = cfg.MaxDelay
+	if maxDelay := float64(cfg.MaxDelay); maxDelay > 0 && backoff > maxDelay {
+		backoff = maxDelay
 	}
-	return delay
+	// Equal jitter: keep half of the backoff and randomize the other half so
+	// that concurrent clients do not retry in lockstep.
+	half := backoff / 2
+	jitter := rand.Float64() * half //nolint:gosec // jitter does not need a cryptographically secure source
+	return time.Duration(half + jitter)
 }
 
 // calculateBackoff computes the backoff delay for a given retry attempt
 func (r *Retrier) calculateBackoff(attempt int) time.Duration {
-	backoff := float64(r.config.BaseDelay) * math.Pow(2, float64(attempt))
-	jitter := time.Duration(backoff / 2 * (1 - (0.5 * math.Sin(float64(attempt)))))
-	delay := time.Duration(backoff) + jitter
-	if delay > r.config.MaxDelay {
-		delay = r.config.MaxDelay
+	return calculateBackoff(&r.config, attempt)
+}
+
+// drainAndClose discards any unread body so the underlying connection can be reused.
+func drainAndClose(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
 	}
-	return delay
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
 }
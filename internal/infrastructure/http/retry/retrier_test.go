@@ -58,6 +58,30 @@ func TestRetrier_Do_RetryOnStatus(t *testing.T) {
 	}
 }
 
+func TestRetrier_Do_RetryAfterBeyondMaxDelay(t *testing.T) {
+	calls := 0
+	r := NewRetrier(Config{
+		MaxRetries:           3,
+		BaseDelay:            time.Millisecond,
+		MaxDelay:             time.Second,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests},
+	})
+	start := time.Now()
+	resp, err := r.Do(context.Background(), func() (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"3600"}}, Body: http.NoBody}, nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || calls != 1 {
+		t.Errorf("expected the 429 back after 1 call, got %d after %d", resp.StatusCode, calls)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected no wait, waited %v", elapsed)
+	}
+}
+
 func TestRetrier_Do_ContextCancel(t *testing.T) {
 	r := NewRetrier(DefaultConfig)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
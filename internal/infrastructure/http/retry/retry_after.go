@@ -0,0 +1,61 @@
+// Package retry provides retry logic for HTTP requests in the Huntress API client.
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resetEpochThreshold separates X-RateLimit-Reset values given as Unix
+// timestamps from values given as a number of seconds to wait.
+const resetEpochThreshold = 1_000_000_000
+
+// RetryAfter returns how long the server asked the client to wait before
+// sending another request. It understands the standard Retry-After header
+// (delta-seconds or HTTP-date) and the X-RateLimit-Reset header (Unix
+// timestamp or delta-seconds). X-RateLimit-Reset is only consulted when the
+// response indicates the rate limit is actually exhausted, since the API sends
+// it on every response. The second return value is false when the response
+// carries no usable hint.
+func RetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if v := strings.TrimSpace(resp.Header.Get("Retry-After")); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return clampWait(time.Duration(secs) * time.Second), true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return clampWait(at.Sub(now)), true
+		}
+	}
+	if !rateLimitExhausted(resp) {
+		return 0, false
+	}
+	if v := strings.TrimSpace(resp.Header.Get("X-RateLimit-Reset")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if n >= resetEpochThreshold {
+				return clampWait(time.Unix(n, 0).Sub(now)), true
+			}
+			return clampWait(time.Duration(n) * time.Second), true
+		}
+	}
+	return 0, false
+}
+
+// rateLimitExhausted reports whether the response signals that no request
+// budget remains in the current window.
+func rateLimitExhausted(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		strings.TrimSpace(resp.Header.Get("X-RateLimit-Remaining")) == "0"
+}
+
+// clampWait ensures a server supplied delay is never negative.
+func clampWait(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
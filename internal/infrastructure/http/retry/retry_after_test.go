@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}}, 7 * time.Second, true},
+		{"http date", http.StatusServiceUnavailable, http.Header{"Retry-After": {now.Add(3 * time.Second).Format(http.TimeFormat)}}, 3 * time.Second, true},
+		{"past date", http.StatusServiceUnavailable, http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0, true},
+		{"reset epoch", http.StatusTooManyRequests, http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(12*time.Second).Unix(), 10)}}, 12 * time.Second, true},
+		{"reset delta", http.StatusTooManyRequests, http.Header{"X-Ratelimit-Reset": {"5"}}, 5 * time.Second, true},
+		{"reset ignored when budget remains", http.StatusServiceUnavailable, http.Header{"X-Ratelimit-Reset": {"5"}, "X-Ratelimit-Remaining": {"10"}}, 0, false},
+		{"no hint", http.StatusServiceUnavailable, http.Header{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RetryAfter(&http.Response{StatusCode: tt.status, Header: tt.header}, now)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("RetryAfter() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCalculateBackoff_JitterWithinBounds(t *testing.T) {
+	cfg := Config{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 0; attempt < 6; attempt++ {
+		d := calculateBackoff(&cfg, attempt)
+		upper := cfg.BaseDelay << attempt
+		if upper > cfg.MaxDelay {
+			upper = cfg.MaxDelay
+		}
+		if d < upper/2 || d > upper {
+			t.Errorf("attempt %d: backoff %v outside [%v, %v]", attempt, d, upper/2, upper)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package redact removes credentials and personal data from requests,
+// responses and log output before they leave the process.
+//
+// Sensitive JSON keys are recognized by name: any key containing
+// "password", "secret", "token", "apikey", "authorization", "credential" or
+// "privatekey", ignoring case, "-" and "_", has its value replaced. Email
+// addresses and known secret values can additionally be scrubbed from
+// string values.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Placeholder replaces redacted values.
+const Placeholder = "[REDACTED]"
+
+// EmailPlaceholder replaces redacted email addresses. It is itself a valid
+// address, so redacted data still passes format checks.
+const EmailPlaceholder = "redacted@example.com"
+
+// sensitiveKeyParts are substrings of normalized keys whose values are
+// redacted.
+var sensitiveKeyParts = []string{"password", "secret", "token", "apikey", "authorization", "credential", "privatekey"}
+
+// sensitiveHeaders are redacted in addition to headers with sensitive names.
+var sensitiveHeaders = map[string]bool{
+	"Cookie":     true,
+	"Set-Cookie": true,
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+var keyNormalizer = strings.NewReplacer("_", "", "-", "")
+
+// Redactor redacts sensitive keys, plus any listed in Keys. The zero value
+// redacts the built-in keys only.
+type Redactor struct {
+	// Keys are additional key substrings to redact, matched like the
+	// built-in ones
+	Keys []string
+	// Emails replaces email addresses in string values and query
+	// parameters with EmailPlaceholder
+	Emails bool
+	// Secrets are literal values, such as API credentials, replaced with
+	// Placeholder wherever they appear in string values. Empty entries are
+	// ignored.
+	Secrets []string
+}
+
+// IsSensitiveKey reports whether a JSON key, query parameter or header
+// name holds a secret.
+func (r Redactor) IsSensitiveKey(key string) bool {
+	k := keyNormalizer.Replace(strings.ToLower(key))
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	for _, part := range r.Keys {
+		if strings.Contains(k, keyNormalizer.Replace(strings.ToLower(part))) {
+			return true
+		}
+	}
+	return false
+}
+
+// String scrubs r.Secrets, and email addresses when r.Emails is set, from s.
+func (r Redactor) String(s string) string {
+	for _, secret := range r.Secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Placeholder)
+		}
+	}
+	if !r.Emails {
+		return s
+	}
+	return emailPattern.ReplaceAllString(s, EmailPlaceholder)
+}
+
+// JSON returns a copy of a JSON document with sensitive values replaced.
+// ok is false when body is not a single JSON document, in which case body
+// is returned unchanged.
+func (r Redactor) JSON(body []byte) (out []byte, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return body, false
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r.value(doc)); err != nil {
+		return body, false
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), true
+}
+
+// Body redacts a JSON body. Bodies that are not JSON are summarized by
+// size, since their content cannot be checked.
+func (r Redactor) Body(body []byte) []byte {
+	if len(body) == 0 {
+		return nil
+	}
+	if out, ok := r.JSON(body); ok {
+		return out
+	}
+	return []byte(fmt.Sprintf("[%d bytes, not JSON]", len(body)))
+}
+
+// Header returns a copy of h with credentials and cookies replaced.
+func (r Redactor) Header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] || r.IsSensitiveKey(k) {
+			out[k] = []string{Placeholder}
+			continue
+		}
+		cp := make([]string, len(vs))
+		for i, v := range vs {
+			cp[i] = r.String(v)
+		}
+		out[k] = cp
+	}
+	return out
+}
+
+// Query returns a copy of q with sensitive parameters replaced.
+func (r Redactor) Query(q url.Values) url.Values {
+	out := make(url.Values, len(q))
+	for k, vs := range q {
+		cp := make([]string, len(vs))
+		for i, v := range vs {
+			if r.IsSensitiveKey(k) {
+				cp[i] = Placeholder
+			} else {
+				cp[i] = r.String(v)
+			}
+		}
+		out[k] = cp
+	}
+	return out
+}
+
+// URL returns u as a string with user info and sensitive query parameters
+// redacted.
+func (r Redactor) URL(u *url.URL) string {
+	cp := *u
+	if cp.User != nil {
+		cp.User = url.User(Placeholder)
+	}
+	if cp.RawQuery != "" {
+		cp.RawQuery = r.Query(cp.Query()).Encode()
+	}
+	return cp.String()
+}
+
+// Value redacts a log field value stored under key. Values of sensitive
+// keys are replaced outright. Strings, errors, headers, URLs and query
+// parameters are scrubbed, and a string holding a JSON document is redacted
+// as one. Numbers, booleans and times are returned unchanged. Anything
+// else, such as a struct or map, is converted to its JSON form and
+// redacted, so a logged account.Account never shows its WebhookPassword.
+func (r Redactor) Value(key string, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if r.IsSensitiveKey(key) {
+		return Placeholder
+	}
+	switch v := v.(type) {
+	case string:
+		return r.text(v)
+	case []byte:
+		return string(r.Body(v))
+	case error:
+		return redactedError{msg: r.text(v.Error()), err: v}
+	case http.Header:
+		return r.Header(v)
+	case url.Values:
+		return r.Query(v)
+	case *url.URL:
+		if v == nil {
+			return v
+		}
+		return r.URL(v)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, time.Duration, time.Time:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return r.String(fmt.Sprint(v))
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return r.String(string(b))
+	}
+	return r.value(doc)
+}
+
+// text scrubs a free-form string, redacting it as JSON when it holds a
+// JSON document, such as a logged response body.
+func (r Redactor) text(s string) string {
+	if t := strings.TrimSpace(s); strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[") {
+		if out, ok := r.JSON([]byte(t)); ok {
+			return string(out)
+		}
+	}
+	return r.String(s)
+}
+
+// redactedError is an error whose message has been redacted. It unwraps to
+// the original, so errors.Is and errors.As still see through it.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e redactedError) Error() string { return e.msg }
+func (e redactedError) Unwrap() error { return e.err }
+
+func (r Redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if val != nil && r.IsSensitiveKey(k) {
+				v[k] = Placeholder
+			} else {
+				v[k] = r.value(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = r.value(val)
+		}
+	case string:
+		return r.String(v)
+	}
+	return v
+}
+
+// JSON redacts the built-in sensitive keys in a JSON document; see
+// Redactor.JSON.
+func JSON(body []byte) ([]byte, bool) {
+	return Redactor{}.JSON(body)
+}
+
+// Body redacts the built-in sensitive keys in a body; see Redactor.Body.
+func Body(body []byte) []byte {
+	return Redactor{}.Body(body)
+}
+
+// Header redacts credentials and cookies; see Redactor.Header.
+func Header(h http.Header) http.Header {
+	return Redactor{}.Header(h)
+}
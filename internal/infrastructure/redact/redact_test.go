@@ -0,0 +1,91 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{"api_secret":"s","nested":[{"Token":"t","name":"n"}]}`, `{"api_secret":"[REDACTED]","nested":[{"Token":"[REDACTED]","name":"n"}]}`},
+		{`{"WebhookPassword":"p","password":null}`, `{"WebhookPassword":"[REDACTED]","password":null}`},
+		{`{"count":12345678901234567890}`, `{"count":12345678901234567890}`},
+		{`not json`, `[8 bytes, not JSON]`},
+		{``, ``},
+	}
+	for _, tt := range tests {
+		if got := string(Body([]byte(tt.in))); got != tt.want {
+			t.Errorf("Body(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactor_Emails(t *testing.T) {
+	r := Redactor{Emails: true, Keys: []string{"phone"}}
+	got, ok := r.JSON([]byte(`{"contact":"Jo <jo.doe+x@corp.example.org>","mobile_phone":"555"}`))
+	if !ok {
+		t.Fatal("expected JSON to be redacted")
+	}
+	if want := `{"contact":"Jo <redacted@example.com>","mobile_phone":"[REDACTED]"}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestHeaderAndURL(t *testing.T) {
+	h := http.Header{"Authorization": {"Basic abc"}, "Cookie": {"s=1"}, "X-Api-Key": {"k"}, "Accept": {"application/json"}}
+	got := Header(h)
+	for _, k := range []string{"Authorization", "Cookie", "X-Api-Key"} {
+		if got.Get(k) != Placeholder {
+			t.Errorf("%s = %q, want redacted", k, got.Get(k))
+		}
+	}
+	if got.Get("Accept") != "application/json" {
+		t.Errorf("Accept = %q", got.Get("Accept"))
+	}
+	if h.Get("Authorization") != "Basic abc" {
+		t.Error("Header modified its input")
+	}
+
+	u, _ := url.Parse("https://user:pw@api.example.com/v1/x?access_token=t&page=2")
+	if got, want := (Redactor{}).URL(u), "https://%5BREDACTED%5D@api.example.com/v1/x?access_token=%5BREDACTED%5D&page=2"; got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+}
+
+func TestRedactor_Value(t *testing.T) {
+	r := Redactor{Emails: true, Secrets: []string{"s3cr3t", ""}}
+	type account struct {
+		Name            string `json:"name"`
+		Email           string `json:"email"`
+		WebhookPassword string `json:"webhookPassword"`
+	}
+	tests := []struct {
+		key  string
+		in   interface{}
+		want interface{}
+	}{
+		{"api_secret", "anything", Placeholder},
+		{"url", "https://x/?key=s3cr3t", "https://x/?key=" + Placeholder},
+		{"body", `{"token":"t","owner":"jo@corp.example.org"}`, `{"owner":"redacted@example.com","token":"[REDACTED]"}`},
+		{"status", 404, 404},
+		{"account", account{Name: "Acme", Email: "ops@acme.example", WebhookPassword: "p"},
+			map[string]interface{}{"name": "Acme", "email": EmailPlaceholder, "webhookPassword": Placeholder}},
+	}
+	for _, tt := range tests {
+		got := r.Value(tt.key, tt.in)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Value(%q, %v) = %v, want %v", tt.key, tt.in, got, tt.want)
+		}
+	}
+
+	base := errors.New("auth failed for jo@corp.example.org with s3cr3t")
+	err, ok := r.Value("error", base).(error)
+	if !ok || err.Error() != "auth failed for redacted@example.com with [REDACTED]" || !errors.Is(err, base) {
+		t.Errorf("Value(error) = %v, want a scrubbed error wrapping the original", err)
+	}
+}
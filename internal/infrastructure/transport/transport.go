@@ -0,0 +1,59 @@
+// Package transport defines the request pipeline shared by the public client
+// and the internal API adapters.
+//
+// pkg/huntress builds the one pipeline (auth, rate limiting, retries, circuit
+// breaking, caching and logging) and hands it to every adapter as a Doer.
+// Adapters send requests through the Doer they are given instead of holding
+// their own *http.Client, so the same policies and error types apply no
+// matter which code path issues a request. A Doer built by pkg/huntress
+// returns a typed error alongside the response for every non-2xx status,
+// with the response body already buffered.
+package transport
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Doer sends an HTTP request. *http.Client satisfies Doer, which keeps
+// adapters usable on their own in tests.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts an ordinary function to the Doer interface.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// PageInfo is the pagination state the Huntress API reports in response
+// headers.
+type PageInfo struct {
+	Page       int
+	PerPage    int
+	TotalPages int
+	TotalItems int
+}
+
+// PageInfoFromHeaders reads X-Page, X-Per-Page, X-Total-Pages and
+// X-Total-Items (or X-Total-Count) from h. Missing or malformed headers
+// leave the field zero; ok reports whether any header was present.
+func PageInfoFromHeaders(h http.Header) (info PageInfo, ok bool) {
+	read := func(field *int, names ...string) {
+		for _, name := range names {
+			if v, err := strconv.Atoi(h.Get(name)); err == nil {
+				*field = v
+				ok = true
+				return
+			}
+		}
+	}
+	read(&info.Page, "X-Page")
+	read(&info.PerPage, "X-Per-Page")
+	read(&info.TotalPages, "X-Total-Pages")
+	read(&info.TotalItems, "X-Total-Items", "X-Total-Count")
+	return info, ok
+}
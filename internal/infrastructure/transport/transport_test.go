@@ -0,0 +1,30 @@
+package transport
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPageInfoFromHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Page", "2")
+	h.Set("X-Per-Page", "50")
+	h.Set("X-Total-Pages", "4")
+	h.Set("X-Total-Count", "180")
+	info, ok := PageInfoFromHeaders(h)
+	if !ok {
+		t.Fatal("expected headers to be found")
+	}
+	if want := (PageInfo{Page: 2, PerPage: 50, TotalPages: 4, TotalItems: 180}); info != want {
+		t.Errorf("got %+v, want %+v", info, want)
+	}
+
+	h.Set("X-Total-Items", "175")
+	if info, _ := PageInfoFromHeaders(h); info.TotalItems != 175 {
+		t.Errorf("expected X-Total-Items to take precedence, got %d", info.TotalItems)
+	}
+
+	if info, ok := PageInfoFromHeaders(http.Header{"X-Page": {"abc"}}); ok || info != (PageInfo{}) {
+		t.Errorf("expected malformed headers to be ignored, got %+v, %v", info, ok)
+	}
+}
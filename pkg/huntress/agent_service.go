@@ -2,9 +2,10 @@
 package huntress
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"io"
+	"iter"
 	"net/http"
 	"os"
 )
@@ -51,6 +52,32 @@ func (s *agentService) List(ctx context.Context, params *AgentListOptions) ([]*A
 	return agents, pagination, nil
 }
 
+// All iterates over every agent matching params, fetching pages lazily
+func (s *agentService) All(ctx context.Context, params *AgentListOptions) iter.Seq2[*Agent, error] {
+	var opts AgentListOptions
+	if params != nil {
+		opts = *params
+	}
+	return paginate(ctx, opts.Page, opts.PerPage, func(ctx context.Context, page int) ([]*Agent, *Pagination, error) {
+		opts.Page = page
+		return s.List(ctx, &opts)
+	}, func(a *Agent) string { return a.ID })
+}
+
+// AllParallel iterates over every agent matching params, fetching pages
+// concurrently once the first response reports the total page count
+func (s *agentService) AllParallel(ctx context.Context, params *AgentListOptions, opts *PrefetchOptions) iter.Seq2[*Agent, error] {
+	var base AgentListOptions
+	if params != nil {
+		base = *params
+	}
+	return prefetch(ctx, base.Page, base.PerPage, func(ctx context.Context, page int) ([]*Agent, *Pagination, error) {
+		p := base
+		p.Page = page
+		return s.List(ctx, &p)
+	}, func(a *Agent) string { return a.ID }, opts)
+}
+
 // GetStats retrieves statistics for a specific agent
 func (s *agentService) GetStats(ctx context.Context, id string) (*AgentStatistics, error) {
 	path := fmt.Sprintf("/agents/%s/stats", id)
@@ -97,6 +124,33 @@ func (s *agentService) Update(ctx context.Context, id string, agent map[string]i
 	return updatedAgent, nil
 }
 
+// Patch validates params and sends them as a JSON Merge Patch or JSON Patch
+func (s *agentService) Patch(ctx context.Context, id string, params *AgentUpdateParams, format PatchFormat) (*Agent, error) {
+	body, contentType, err := params.encode(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent update: %w", err)
+	}
+	path := fmt.Sprintf("/agents/%s", id)
+	req, err := s.client.NewRequest(ctx, http.MethodPatch, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	updatedAgent := new(Agent)
+	resp, err := s.client.Do(ctx, req, updatedAgent)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "error closing response body: %v\n", err)
+		}
+	}()
+
+	return updatedAgent, nil
+}
+
 // Delete removes an agent
 func (s *agentService) Delete(ctx context.Context, id string) error {
 	path := fmt.Sprintf("/agents/%s", id)
@@ -114,12 +168,5 @@ func (s *agentService) Delete(ctx context.Context, id string) error {
 			fmt.Fprintf(os.Stderr, "error closing response body: %v\n", err)
 		}
 	}()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("API error: status code %d, failed to read body: %w", resp.StatusCode, err)
-		}
-		return fmt.Errorf("API error: status code %d, body: %s", resp.StatusCode, string(bodyBytes))
-	}
 	return nil
 }
@@ -0,0 +1,241 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Media types for partial updates.
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// PatchFormat selects how a partial update is encoded.
+type PatchFormat int
+
+const (
+	// MergePatch encodes updates as a JSON Merge Patch (RFC 7396).
+	MergePatch PatchFormat = iota
+	// JSONPatchFormat encodes updates as a JSON Patch (RFC 6902).
+	JSONPatchFormat
+)
+
+// JSONPatchOp is a single JSON Patch (RFC 6902) operation.
+type JSONPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JSONPatch is a JSON Patch (RFC 6902) document.
+type JSONPatch []JSONPatchOp
+
+// AgentUpdateParams is a typed partial update for an agent. Nil fields are
+// left unchanged.
+type AgentUpdateParams struct {
+	// Tags replaces the agent's tags. A pointer to an empty slice clears them.
+	Tags *[]string
+	// Settings replaces the agent's auto_update, monitor_processes and
+	// monitor_services flags. Its CustomSettings must be nil; change
+	// custom settings through CustomSettings instead.
+	Settings *AgentSettings
+	// CustomSettings sets or, for nil values, removes individual custom
+	// settings. Settings not listed are left unchanged.
+	CustomSettings map[string]*string
+	// CustomSettingsAbsent reports that the agent has no custom settings
+	// yet. A JSON Patch cannot add a member to a missing object, so
+	// JSONPatch then adds custom_settings whole, holding the new values.
+	// DiffAgents sets it when the original agent has none.
+	CustomSettingsAbsent bool
+}
+
+// IsEmpty reports whether p changes nothing.
+func (p *AgentUpdateParams) IsEmpty() bool {
+	return p == nil || (p.Tags == nil && p.Settings == nil && len(p.CustomSettings) == 0)
+}
+
+// Validate checks that the params describe a valid, non-empty update.
+func (p *AgentUpdateParams) Validate() error {
+	if p.IsEmpty() {
+		return errors.New("agent update: nothing to update")
+	}
+	if p.Tags != nil {
+		seen := make(map[string]bool, len(*p.Tags))
+		for _, tag := range *p.Tags {
+			if strings.TrimSpace(tag) == "" {
+				return errors.New("agent update: tags must not be blank")
+			}
+			if seen[tag] {
+				return fmt.Errorf("agent update: duplicate tag %q", tag)
+			}
+			seen[tag] = true
+		}
+	}
+	if p.Settings != nil && p.Settings.CustomSettings != nil {
+		return errors.New("agent update: set custom settings through CustomSettings, not Settings")
+	}
+	for key := range p.CustomSettings {
+		if strings.TrimSpace(key) == "" {
+			return errors.New("agent update: custom setting keys must not be blank")
+		}
+	}
+	return nil
+}
+
+// MergePatch encodes p as a JSON Merge Patch (RFC 7396) document.
+func (p *AgentUpdateParams) MergePatch() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	doc := make(map[string]interface{})
+	if p.Tags != nil {
+		doc["tags"] = nonNilTags(*p.Tags)
+	}
+	settings := make(map[string]interface{})
+	if p.Settings != nil {
+		settings["auto_update"] = p.Settings.AutoUpdate
+		settings["monitor_processes"] = p.Settings.MonitorProcesses
+		settings["monitor_services"] = p.Settings.MonitorServices
+	}
+	if len(p.CustomSettings) > 0 {
+		custom := make(map[string]interface{}, len(p.CustomSettings))
+		for k, v := range p.CustomSettings {
+			if v == nil {
+				custom[k] = nil
+			} else {
+				custom[k] = *v
+			}
+		}
+		settings["custom_settings"] = custom
+	}
+	if len(settings) > 0 {
+		doc["settings"] = settings
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encoding merge patch: %w", err)
+	}
+	return out, nil
+}
+
+// JSONPatch returns p as JSON Patch (RFC 6902) operations. Removing a
+// custom setting the agent does not have makes the server reject the patch,
+// as does adding one to an agent without custom settings unless
+// CustomSettingsAbsent is set.
+func (p *AgentUpdateParams) JSONPatch() (JSONPatch, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	var ops JSONPatch
+	if p.Tags != nil {
+		ops = append(ops, JSONPatchOp{Op: "replace", Path: "/tags", Value: nonNilTags(*p.Tags)})
+	}
+	if p.Settings != nil {
+		ops = append(ops,
+			JSONPatchOp{Op: "replace", Path: "/settings/auto_update", Value: p.Settings.AutoUpdate},
+			JSONPatchOp{Op: "replace", Path: "/settings/monitor_processes", Value: p.Settings.MonitorProcesses},
+			JSONPatchOp{Op: "replace", Path: "/settings/monitor_services", Value: p.Settings.MonitorServices},
+		)
+	}
+	if p.CustomSettingsAbsent {
+		custom := make(map[string]string, len(p.CustomSettings))
+		for k, v := range p.CustomSettings {
+			if v != nil {
+				custom[k] = *v
+			}
+		}
+		if len(custom) > 0 {
+			ops = append(ops, JSONPatchOp{Op: "add", Path: "/settings/custom_settings", Value: custom})
+		}
+		return ops, nil
+	}
+	keys := slices.Collect(maps.Keys(p.CustomSettings))
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := "/settings/custom_settings/" + escapeJSONPointer(k)
+		if v := p.CustomSettings[k]; v != nil {
+			ops = append(ops, JSONPatchOp{Op: "add", Path: path, Value: *v})
+		} else {
+			ops = append(ops, JSONPatchOp{Op: "remove", Path: path})
+		}
+	}
+	return ops, nil
+}
+
+// encode returns the request body and content type for format.
+func (p *AgentUpdateParams) encode(format PatchFormat) ([]byte, string, error) {
+	switch format {
+	case MergePatch:
+		body, err := p.MergePatch()
+		return body, MergePatchContentType, err
+	case JSONPatchFormat:
+		ops, err := p.JSONPatch()
+		if err != nil {
+			return nil, "", err
+		}
+		body, err := json.Marshal(ops)
+		if err != nil {
+			return nil, "", fmt.Errorf("encoding JSON patch: %w", err)
+		}
+		return body, JSONPatchContentType, nil
+	}
+	return nil, "", fmt.Errorf("agent update: unknown patch format %d", format)
+}
+
+// DiffAgents returns the smallest AgentUpdateParams that turns original
+// into modified. Only updatable fields (tags, settings and custom settings)
+// are compared. The result is empty when they already match; check with
+// IsEmpty.
+func DiffAgents(original, modified *Agent) *AgentUpdateParams {
+	p := &AgentUpdateParams{}
+	if original == nil || modified == nil {
+		return p
+	}
+	if !slices.Equal(original.Tags, modified.Tags) {
+		tags := slices.Clone(modified.Tags)
+		p.Tags = &tags
+	}
+	o, m := original.Settings, modified.Settings
+	if o.AutoUpdate != m.AutoUpdate || o.MonitorProcesses != m.MonitorProcesses || o.MonitorServices != m.MonitorServices {
+		p.Settings = &AgentSettings{AutoUpdate: m.AutoUpdate, MonitorProcesses: m.MonitorProcesses, MonitorServices: m.MonitorServices}
+	}
+	for k, v := range m.CustomSettings {
+		if old, ok := o.CustomSettings[k]; !ok || old != v {
+			if p.CustomSettings == nil {
+				p.CustomSettings = make(map[string]*string)
+			}
+			p.CustomSettings[k] = &v
+		}
+	}
+	for k := range o.CustomSettings {
+		if _, ok := m.CustomSettings[k]; !ok {
+			if p.CustomSettings == nil {
+				p.CustomSettings = make(map[string]*string)
+			}
+			p.CustomSettings[k] = nil
+		}
+	}
+	p.CustomSettingsAbsent = len(o.CustomSettings) == 0 && len(p.CustomSettings) > 0
+	return p
+}
+
+// nonNilTags makes an empty tag list encode as [] rather than null, which
+// a merge patch would read as "remove".
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// escapeJSONPointer escapes a JSON Pointer (RFC 6901) reference token.
+func escapeJSONPointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,167 @@
+package huntress
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestAgentUpdateParams_Validate(t *testing.T) {
+	dup := []string{"a", "a"}
+	blank := []string{" "}
+	tests := []struct {
+		name    string
+		params  *AgentUpdateParams
+		wantErr bool
+	}{
+		{"nil", nil, true},
+		{"empty", &AgentUpdateParams{}, true},
+		{"duplicate tags", &AgentUpdateParams{Tags: &dup}, true},
+		{"blank tag", &AgentUpdateParams{Tags: &blank}, true},
+		{"custom in settings", &AgentUpdateParams{Settings: &AgentSettings{CustomSettings: map[string]string{"k": "v"}}}, true},
+		{"blank custom key", &AgentUpdateParams{CustomSettings: map[string]*string{"": strPtr("v")}}, true},
+		{"clear tags", &AgentUpdateParams{Tags: &[]string{}}, false},
+		{"settings", &AgentUpdateParams{Settings: &AgentSettings{AutoUpdate: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAgentUpdateParams_MergePatch(t *testing.T) {
+	params := &AgentUpdateParams{
+		Tags:           &[]string{},
+		Settings:       &AgentSettings{MonitorServices: true},
+		CustomSettings: map[string]*string{"keep": strPtr("1"), "drop": nil},
+	}
+	body, err := params.MergePatch()
+	if err != nil {
+		t.Fatalf("MergePatch: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("decoding patch: %v", err)
+	}
+	want := map[string]interface{}{
+		"tags": []interface{}{},
+		"settings": map[string]interface{}{
+			"auto_update":       false,
+			"monitor_processes": false,
+			"monitor_services":  true,
+			"custom_settings":   map[string]interface{}{"keep": "1", "drop": nil},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merge patch = %s", body)
+	}
+}
+
+func TestAgentUpdateParams_JSONPatch(t *testing.T) {
+	tags := []string{"prod"}
+	params := &AgentUpdateParams{
+		Tags:           &tags,
+		CustomSettings: map[string]*string{"a/b~c": strPtr("x"), "old": nil},
+	}
+	ops, err := params.JSONPatch()
+	if err != nil {
+		t.Fatalf("JSONPatch: %v", err)
+	}
+	want := JSONPatch{
+		{Op: "replace", Path: "/tags", Value: []string{"prod"}},
+		{Op: "add", Path: "/settings/custom_settings/a~1b~0c", Value: "x"},
+		{Op: "remove", Path: "/settings/custom_settings/old"},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("JSONPatch() = %+v, want %+v", ops, want)
+	}
+}
+
+func TestAgentUpdateParams_JSONPatch_CustomSettingsAbsent(t *testing.T) {
+	diff := DiffAgents(&Agent{}, &Agent{Settings: AgentSettings{CustomSettings: map[string]string{"b": "2", "a": "1"}}})
+	if !diff.CustomSettingsAbsent {
+		t.Fatal("expected DiffAgents to report the missing custom settings")
+	}
+	ops, err := diff.JSONPatch()
+	if err != nil {
+		t.Fatalf("JSONPatch: %v", err)
+	}
+	want := JSONPatch{{Op: "add", Path: "/settings/custom_settings", Value: map[string]string{"a": "1", "b": "2"}}}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("JSONPatch() = %+v, want %+v", ops, want)
+	}
+
+	diff = DiffAgents(&Agent{Settings: AgentSettings{CustomSettings: map[string]string{"a": "1"}}},
+		&Agent{Settings: AgentSettings{CustomSettings: map[string]string{"a": "1", "b": "2"}}})
+	if diff.CustomSettingsAbsent {
+		t.Error("expected existing custom settings to be patched key by key")
+	}
+}
+
+func TestDiffAgents(t *testing.T) {
+	original := &Agent{
+		Tags:     []string{"a"},
+		Settings: AgentSettings{AutoUpdate: true, CustomSettings: map[string]string{"same": "1", "changed": "1", "gone": "1"}},
+	}
+	modified := &Agent{
+		Tags:     []string{"a"},
+		Settings: AgentSettings{AutoUpdate: true, CustomSettings: map[string]string{"same": "1", "changed": "2", "new": "3"}},
+	}
+	diff := DiffAgents(original, modified)
+	if diff.Tags != nil || diff.Settings != nil {
+		t.Errorf("unchanged fields in diff: %+v", diff)
+	}
+	want := map[string]*string{"changed": strPtr("2"), "new": strPtr("3"), "gone": nil}
+	if !reflect.DeepEqual(diff.CustomSettings, want) {
+		t.Errorf("custom settings diff = %v", diff.CustomSettings)
+	}
+	if !DiffAgents(original, original).IsEmpty() {
+		t.Error("diff of identical agents is not empty")
+	}
+}
+
+func TestAgentService_Patch(t *testing.T) {
+	var contentType, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/agents/a1" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		contentType = r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		_, _ = w.Write([]byte(`{"id":"a1","tags":["prod"]}`))
+	}))
+	defer srv.Close()
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+
+	tags := []string{"prod"}
+	agent, err := client.Agent.Patch(context.Background(), "a1", &AgentUpdateParams{Tags: &tags}, JSONPatchFormat)
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if agent.ID != "a1" {
+		t.Errorf("agent ID = %q", agent.ID)
+	}
+	if contentType != JSONPatchContentType {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if body != `[{"op":"replace","path":"/tags","value":["prod"]}]` {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestAgentService_Patch_Invalid(t *testing.T) {
+	client := New(WithCredentials("k", "s"), WithBaseURL("http://127.0.0.1:0"))
+	if _, err := client.Agent.Patch(context.Background(), "a1", &AgentUpdateParams{}, MergePatch); err == nil {
+		t.Error("expected validation error for empty params")
+	}
+}
@@ -3,6 +3,7 @@ package huntress
 import (
 	"context"
 	"fmt"
+	"iter"
 
 	api "github.com/greysquirr3l/bishoujo-huntress/internal/adapters/api"
 	internal_auditlog "github.com/greysquirr3l/bishoujo-huntress/internal/domain/auditlog"
@@ -65,6 +66,18 @@ func (s *auditLogService) List(ctx context.Context, params *AuditLogListParams)
 	}, nil
 }
 
+// All iterates over every audit log matching params, fetching pages lazily.
+func (s *auditLogService) All(ctx context.Context, params *AuditLogListParams) iter.Seq2[*AuditLog, error] {
+	var opts AuditLogListParams
+	if params != nil {
+		opts = *params
+	}
+	return paginate(ctx, opts.Page, opts.Limit, func(ctx context.Context, page int) ([]*AuditLog, *Pagination, error) {
+		opts.Page = page
+		return s.List(ctx, &opts)
+	}, func(l *AuditLog) string { return l.ID })
+}
+
 // Get returns a single audit log entry by ID.
 func (s *auditLogService) Get(ctx context.Context, id string) (*AuditLog, error) {
 	log, err := s.repo.Get(ctx, id)
@@ -110,7 +123,7 @@ func (a *internalAuditLogRepoAdapter) List(ctx context.Context, params *internal
 // getAPIRepo returns an instance of the internal API adapter for audit logs.
 func (a *internalAuditLogRepoAdapter) getAPIRepo() *api.AuditLogRepository {
 	return &api.AuditLogRepository{
-		Client:    a.client.httpClient,
+		Client:    a.client.transport(),
 		BaseURL:   a.client.baseURL,
 		APIKey:    a.client.apiKey,
 		APISecret: a.client.apiSecret,
@@ -4,6 +4,7 @@ package huntress
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
 )
 
@@ -43,6 +44,18 @@ func (s *billingService) ListInvoices(ctx context.Context, params *ListParams) (
 	return invoices, pagination, nil
 }
 
+// AllInvoices iterates over every invoice, fetching pages lazily
+func (s *billingService) AllInvoices(ctx context.Context, params *ListParams) iter.Seq2[*Invoice, error] {
+	var opts ListParams
+	if params != nil {
+		opts = *params
+	}
+	return paginate(ctx, opts.Page, opts.PerPage, func(ctx context.Context, page int) ([]*Invoice, *Pagination, error) {
+		opts.Page = page
+		return s.ListInvoices(ctx, &opts)
+	}, func(i *Invoice) string { return i.ID })
+}
+
 // GetInvoice retrieves a specific invoice
 func (s *billingService) GetInvoice(ctx context.Context, id string) (*Invoice, error) {
 	path := fmt.Sprintf("/billing/invoices/%s", id)
@@ -1,54 +1,236 @@
-// Package huntress provides a simple in-memory cache for GET requests.
+// Package huntress provides pluggable caches for GET responses.
 package huntress
 
 import (
 	"net/http"
-	"sync"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-type cacheEntry struct {
-	response []byte
-	expiry   time.Time
+// DefaultCacheTTL is used when caching is enabled without WithCacheTTL.
+const DefaultCacheTTL = time.Minute
+
+// Default bounds for the in-memory cache created by WithCacheTTL.
+const (
+	defaultCacheMaxEntries = 1000
+	defaultCacheMaxBytes   = 32 << 20
+)
+
+// CacheEntry is a cached GET response body. An entry is served without
+// contacting the API until FreshUntil; after that it may still be
+// revalidated or served stale until ExpiresAt, when stores drop it.
+type CacheEntry struct {
+	Body         []byte    `json:"body"`
+	StoredAt     time.Time `json:"stored_at"`
+	FreshUntil   time.Time `json:"fresh_until,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+// Expired reports whether the entry is past its expiry time at now.
+func (e *CacheEntry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Fresh reports whether the entry can be served at now without contacting
+// the API.
+func (e *CacheEntry) Fresh(now time.Time) bool {
+	fu := e.freshUntil()
+	return fu.IsZero() || now.Before(fu)
+}
+
+// freshUntil returns FreshUntil, defaulting to ExpiresAt for entries that
+// do not set it.
+func (e *CacheEntry) freshUntil() time.Time {
+	if e.FreshUntil.IsZero() {
+		return e.ExpiresAt
+	}
+	return e.FreshUntil
+}
+
+// size is the number of bytes an entry counts against a store's byte budget.
+func (e *CacheEntry) size(key string) int64 {
+	return int64(len(key) + len(e.Body))
+}
+
+// CacheStats reports cache effectiveness counters.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+}
+
+// CacheStore is a backend for the GET response cache. Implementations must
+// be safe for concurrent use and must not return expired entries.
+type CacheStore interface {
+	// Get returns the entry stored under key, if present and unexpired.
+	Get(key string) (*CacheEntry, bool)
+	// Set stores entry under key, replacing any previous entry.
+	Set(key string, entry *CacheEntry)
+	// Delete removes the entry stored under key.
+	Delete(key string)
+	// DeleteMatching removes every entry whose key satisfies match and
+	// returns how many were removed.
+	DeleteMatching(match func(key string) bool) int
+	// Stats returns the store's counters.
+	Stats() CacheStats
+}
+
+// cacheCounters holds the hit/miss/eviction counters shared by the stores.
+type cacheCounters struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func (c *cacheCounters) stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// NopCache is a CacheStore that stores nothing. Every lookup is a miss.
+type NopCache struct {
+	counters cacheCounters
+}
+
+// NewNopCache creates a NopCache.
+func NewNopCache() *NopCache {
+	return &NopCache{}
+}
+
+// Get always reports a miss.
+func (c *NopCache) Get(string) (*CacheEntry, bool) {
+	c.counters.misses.Add(1)
+	return nil, false
+}
+
+// Set discards the entry.
+func (c *NopCache) Set(string, *CacheEntry) {}
+
+// Delete does nothing.
+func (c *NopCache) Delete(string) {}
+
+// DeleteMatching does nothing.
+func (c *NopCache) DeleteMatching(func(string) bool) int { return 0 }
+
+// Stats returns the miss count.
+func (c *NopCache) Stats() CacheStats {
+	return c.counters.stats()
+}
+
+// CacheTTL decides how long responses are cached. Paths maps an endpoint
+// path prefix relative to the base URL (e.g. "/incidents") to its TTL; the
+// longest matching prefix wins. A TTL of zero disables caching for that
+// prefix.
+type CacheTTL struct {
+	Default time.Duration
+	Paths   map[string]time.Duration
+}
+
+// For returns the TTL for an endpoint path.
+func (t CacheTTL) For(path string) time.Duration {
+	ttl, longest := t.Default, -1
+	for prefix, d := range t.Paths {
+		if len(prefix) > longest && pathHasPrefix(path, prefix) {
+			ttl, longest = d, len(prefix)
+		}
+	}
+	return ttl
+}
+
+// pathHasPrefix reports whether path equals prefix or continues it with a
+// new segment or query, so "/agents" matches "/agents/1" but not "/agentsx".
+func pathHasPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	rest := path[len(prefix):]
+	return rest == "" || rest[0] == '/' || rest[0] == '?'
+}
+
+// endpointPath returns the path of u relative to the client's base URL,
+// e.g. "/organizations/1" for https://api.huntress.io/v1/organizations/1.
+func (c *Client) endpointPath(u *url.URL) string {
+	if base, err := url.Parse(c.baseURL); err == nil {
+		if p := strings.TrimSuffix(base.Path, "/"); p != "" && strings.HasPrefix(u.Path, p) {
+			return u.Path[len(p):]
+		}
+	}
+	return u.Path
+}
+
+// CacheStats returns the counters of the client's cache store, or zero
+// stats when caching is disabled.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.Stats()
 }
 
 // Cache is a simple in-memory cache for GET requests.
+//
+// Deprecated: Use a CacheStore such as NewLRUCache with WithCacheStore.
 type Cache struct {
-	mu      sync.RWMutex
-	entries map[string]*cacheEntry
-	ttl     time.Duration
+	store *LRUCache
+	ttl   time.Duration
 }
 
 // NewCache creates a new Cache with the given TTL.
+//
+// Deprecated: Use NewLRUCache.
 func NewCache(ttl time.Duration) *Cache {
 	return &Cache{
-		entries: make(map[string]*cacheEntry),
-		ttl:     ttl,
+		store: NewLRUCache(defaultCacheMaxEntries, defaultCacheMaxBytes),
+		ttl:   ttl,
 	}
 }
 
 // Get returns the cached response for the given key, or nil if not found or expired.
 func (c *Cache) Get(key string) []byte {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	entry, ok := c.entries[key]
-	if !ok || time.Now().After(entry.expiry) {
-		return nil
+	if entry, ok := c.store.Get(key); ok {
+		return entry.Body
 	}
-	return entry.response
+	return nil
 }
 
 // Set stores the response for the given key.
 func (c *Cache) Set(key string, response []byte) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.entries[key] = &cacheEntry{
-		response: response,
-		expiry:   time.Now().Add(c.ttl),
-	}
+	now := time.Now()
+	c.store.Set(key, &CacheEntry{Body: response, StoredAt: now, ExpiresAt: now.Add(c.ttl)})
 }
 
-// CacheKey generates a cache key for a GET request.
+// CacheKey generates a cache key for a GET request: the method and URL,
+// followed by the headers set with the RequestHeader option so calls asking
+// for different representations are cached separately. The per-call
+// X-Request-ID is left out.
 func CacheKey(req *http.Request) string {
-	return req.Method + ":" + req.URL.String()
+	key := req.Method + ":" + req.URL.String()
+	ro := requestOptionsFromContext(req.Context())
+	if ro == nil || len(ro.header) == 0 {
+		return key
+	}
+	names := make([]string, 0, len(ro.header))
+	for name := range ro.header {
+		if http.CanonicalHeaderKey(name) != http.CanonicalHeaderKey(RequestIDHeader) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range names {
+		b.WriteString("\n" + name + ": " + strings.Join(req.Header.Values(name), ", "))
+	}
+	return b.String()
 }
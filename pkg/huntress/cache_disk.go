@@ -0,0 +1,256 @@
+// Package huntress provides pluggable caches for GET responses.
+package huntress
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const diskCacheExt = ".json"
+
+// DiskCache is a CacheStore that keeps one file per entry in a directory,
+// so cached responses survive process restarts. Expired files are removed
+// when they are looked up and swept on every Set and DeleteMatching, and the
+// least recently used files are evicted to stay within the size bounds. The
+// bounds cover the files this DiskCache has seen, so a directory should be
+// used by one DiskCache at a time.
+type DiskCache struct {
+	dir        string
+	maxEntries int
+	maxBytes   int64
+	counters   cacheCounters
+
+	mu    sync.Mutex
+	files map[string]diskFile // by file name
+	bytes int64
+}
+
+// diskFile is the index record of an entry file.
+type diskFile struct {
+	size      int64
+	expiresAt time.Time
+	usedAt    time.Time
+}
+
+// diskRecord is the on-disk form of an entry. The key is kept so that a hash
+// collision can never return another request's response.
+type diskRecord struct {
+	Key   string      `json:"key"`
+	Entry *CacheEntry `json:"entry"`
+}
+
+// NewDiskCache creates a DiskCache rooted at dir holding at most maxEntries
+// entry files and maxBytes bytes of them. A bound of zero or less is
+// unlimited. The directory is created with owner-only permissions if it
+// does not exist; existing entries are indexed, and expired ones removed.
+func NewDiskCache(dir string, maxEntries int, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	c := &DiskCache{dir: dir, maxEntries: maxEntries, maxBytes: maxBytes, files: make(map[string]diskFile)}
+	c.scan(c.indexFound)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweep(time.Now())
+	return c, nil
+}
+
+// Get returns the entry stored under key, if present and unexpired.
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path) // #nosec G304 -- path is derived from a hash inside c.dir
+	if err != nil {
+		c.counters.misses.Add(1)
+		return nil, false
+	}
+	var rec diskRecord
+	if err := json.Unmarshal(data, &rec); err != nil || rec.Key != key || rec.Entry == nil {
+		c.counters.misses.Add(1)
+		return nil, false
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := filepath.Base(path)
+	if rec.Entry.Expired(now) {
+		c.evict(name)
+		c.counters.misses.Add(1)
+		return nil, false
+	}
+	if f, ok := c.files[name]; ok {
+		f.usedAt = now
+		c.files[name] = f
+	}
+	c.counters.hits.Add(1)
+	return rec.Entry, true
+}
+
+// Set writes entry to disk, then sweeps expired entries and evicts the
+// least recently used ones to stay within bounds. Writes go through a
+// temporary file and a rename so that concurrent readers never observe a
+// partial entry. Write failures are ignored; the entry is simply not
+// cached, as is an entry larger than maxBytes on its own.
+func (c *DiskCache) Set(key string, entry *CacheEntry) {
+	path := c.path(key)
+	data, err := json.Marshal(diskRecord{Key: key, Entry: entry})
+	if err != nil {
+		return
+	}
+	if c.maxBytes > 0 && int64(len(data)) > c.maxBytes {
+		c.Delete(key)
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index(filepath.Base(path), int64(len(data)), entry.ExpiresAt, now)
+	c.sweep(now)
+}
+
+// Delete removes the entry stored under key.
+func (c *DiskCache) Delete(key string) {
+	path := c.path(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unindex(filepath.Base(path))
+	_ = os.Remove(path)
+}
+
+// DeleteMatching removes every entry whose key satisfies match. Files
+// written by other processes are indexed along the way, and expired
+// entries swept.
+func (c *DiskCache) DeleteMatching(match func(key string) bool) int {
+	n := 0
+	c.scan(func(name string, rec diskRecord, info os.FileInfo) {
+		if match(rec.Key) {
+			c.mu.Lock()
+			c.unindex(name)
+			c.mu.Unlock()
+			if os.Remove(filepath.Join(c.dir, name)) == nil {
+				n++
+			}
+			return
+		}
+		c.indexFound(name, rec, info)
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweep(time.Now())
+	return n
+}
+
+// Stats returns the cache counters and the number and size of entry files.
+func (c *DiskCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.counters.stats()
+	stats.Entries = len(c.files)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// scan calls fn for every readable entry file in c.dir.
+func (c *DiskCache) scan(fn func(name string, rec diskRecord, info os.FileInfo)) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), diskCacheExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.dir, e.Name())) // #nosec G304 -- path is an entry file inside c.dir
+		if err != nil {
+			continue
+		}
+		var rec diskRecord
+		if json.Unmarshal(data, &rec) != nil || rec.Entry == nil {
+			continue
+		}
+		fn(e.Name(), rec, info)
+	}
+}
+
+// index records an entry file. The caller must hold c.mu.
+func (c *DiskCache) index(name string, size int64, expiresAt, usedAt time.Time) {
+	c.unindex(name)
+	c.files[name] = diskFile{size: size, expiresAt: expiresAt, usedAt: usedAt}
+	c.bytes += size
+}
+
+// indexFound records an entry file found in c.dir, keeping the last use of
+// a file already indexed.
+func (c *DiskCache) indexFound(name string, rec diskRecord, info os.FileInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	usedAt := info.ModTime()
+	if f, ok := c.files[name]; ok {
+		usedAt = f.usedAt
+	}
+	c.index(name, info.Size(), rec.Entry.ExpiresAt, usedAt)
+}
+
+// unindex forgets an entry file. The caller must hold c.mu.
+func (c *DiskCache) unindex(name string) {
+	if f, ok := c.files[name]; ok {
+		delete(c.files, name)
+		c.bytes -= f.size
+	}
+}
+
+// evict removes an entry file and counts the eviction. The caller must hold
+// c.mu.
+func (c *DiskCache) evict(name string) {
+	c.unindex(name)
+	if os.Remove(filepath.Join(c.dir, name)) == nil {
+		c.counters.evictions.Add(1)
+	}
+}
+
+// sweep removes expired entries, then the least recently used ones until
+// the cache is within bounds. The caller must hold c.mu.
+func (c *DiskCache) sweep(now time.Time) {
+	for name, f := range c.files {
+		if !f.expiresAt.IsZero() && !now.Before(f.expiresAt) {
+			c.evict(name)
+		}
+	}
+	for (c.maxEntries > 0 && len(c.files) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		oldest := ""
+		for name, f := range c.files {
+			if oldest == "" || f.usedAt.Before(c.files[oldest].usedAt) {
+				oldest = name
+			}
+		}
+		c.evict(oldest)
+	}
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+diskCacheExt)
+}
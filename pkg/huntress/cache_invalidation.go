@@ -0,0 +1,104 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// InvalidationRule returns additional endpoint path prefixes to evict from
+// the cache after a mutating request. method is the request method and path
+// the endpoint path relative to the base URL, e.g. "/organizations/42".
+// Returned prefixes are matched like the argument to Client.InvalidateCache.
+type InvalidationRule func(method, path string) []string
+
+// InvalidateCache evicts every cached response whose endpoint path is prefix
+// or lies beneath it, whatever its query string. "/organizations/1" evicts
+// "/organizations/1" and "/organizations/1/users" but not
+// "/organizations/10". An empty prefix clears the cache. It returns the
+// number of entries removed.
+func (c *Client) InvalidateCache(prefix string) int {
+	if c.cache == nil {
+		return 0
+	}
+	prefix = normalizeCachePath(prefix)
+	return c.cache.DeleteMatching(func(key string) bool {
+		path, ok := c.cacheKeyPath(key)
+		return ok && (prefix == "" || pathHasPrefix(path, prefix))
+	})
+}
+
+// isMutation reports whether method changes server state.
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// invalidateAfterWrite evicts entries made stale by a write to path: the
+// resource itself, every enclosing collection (with any query string, so
+// filtered listings go too) and, unless the write is a POST that only adds
+// a child, everything beneath the resource. Registered InvalidationRules
+// add further prefixes.
+func (c *Client) invalidateAfterWrite(method, path string) {
+	path = normalizeCachePath(path)
+	var exact []string
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := range segments {
+		exact = append(exact, "/"+strings.Join(segments[:i+1], "/"))
+	}
+	subtree := method != http.MethodPost
+	var extra []string
+	for _, rule := range c.invalidationRules {
+		for _, p := range rule(method, path) {
+			extra = append(extra, normalizeCachePath(p))
+		}
+	}
+
+	c.cache.DeleteMatching(func(key string) bool {
+		p, ok := c.cacheKeyPath(key)
+		if !ok {
+			return false
+		}
+		if subtree && pathHasPrefix(p, path) {
+			return true
+		}
+		for _, e := range exact {
+			if p == e {
+				return true
+			}
+		}
+		for _, prefix := range extra {
+			if pathHasPrefix(p, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// cacheKeyPath extracts the endpoint path from a key built by CacheKey.
+func (c *Client) cacheKeyPath(key string) (string, bool) {
+	raw, ok := strings.CutPrefix(key, http.MethodGet+":")
+	if !ok {
+		return "", false
+	}
+	raw, _, _ = strings.Cut(raw, "\n")
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	return normalizeCachePath(c.endpointPath(u)), true
+}
+
+// normalizeCachePath strips a trailing slash so "/agents/" and "/agents"
+// compare equal.
+func normalizeCachePath(path string) string {
+	if path == "/" {
+		return ""
+	}
+	return strings.TrimSuffix(path, "/")
+}
@@ -0,0 +1,123 @@
+// Package huntress provides pluggable caches for GET responses.
+package huntress
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRUCache is an in-memory CacheStore bounded by entry count and total
+// size. When either bound is exceeded the least recently used entries are
+// evicted. Expired entries are dropped as soon as they are looked up.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	order      *list.List
+	items      map[string]*list.Element
+	counters   cacheCounters
+}
+
+type lruItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewLRUCache creates an LRUCache holding at most maxEntries entries and
+// maxBytes bytes of keys and bodies. A bound of zero or less is unlimited.
+func NewLRUCache(maxEntries int, maxBytes int64) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry stored under key, if present and unexpired.
+func (c *LRUCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.counters.misses.Add(1)
+		return nil, false
+	}
+	item := el.Value.(*lruItem)
+	if item.entry.Expired(time.Now()) {
+		c.remove(el)
+		c.counters.evictions.Add(1)
+		c.counters.misses.Add(1)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.counters.hits.Add(1)
+	return item.entry, true
+}
+
+// Set stores entry under key and evicts old entries to stay within bounds.
+// An entry larger than maxBytes on its own is not stored.
+func (c *LRUCache) Set(key string, entry *CacheEntry) {
+	size := entry.size(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	c.bytes += size
+	for c.overLimit() {
+		c.remove(c.order.Back())
+		c.counters.evictions.Add(1)
+	}
+}
+
+// Delete removes the entry stored under key.
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeleteMatching removes every entry whose key satisfies match.
+func (c *LRUCache) DeleteMatching(match func(key string) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for key, el := range c.items {
+		if match(key) {
+			c.remove(el)
+			n++
+		}
+	}
+	return n
+}
+
+// Stats returns the cache counters and current size.
+func (c *LRUCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.counters.stats()
+	stats.Entries = len(c.items)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+func (c *LRUCache) overLimit() bool {
+	return (c.maxEntries > 0 && len(c.items) > c.maxEntries) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+// remove unlinks el. The caller must hold c.mu.
+func (c *LRUCache) remove(el *list.Element) {
+	item := c.order.Remove(el).(*lruItem)
+	delete(c.items, item.key)
+	c.bytes -= item.entry.size(item.key)
+}
@@ -0,0 +1,239 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+)
+
+// cacheStatusHeader reports the cache outcome on responses returned by Do.
+const cacheStatusHeader = "X-Huntress-Cache"
+
+// Cache outcomes reported by CacheStatus.
+const (
+	// CacheMiss means the response came from the API and may have been stored.
+	CacheMiss = "MISS"
+	// CacheHit means a fresh cached response was served without contacting the API.
+	CacheHit = "HIT"
+	// CacheRevalidated means the API confirmed with 304 Not Modified that the
+	// cached response is still current.
+	CacheRevalidated = "REVALIDATED"
+	// CacheStale means an expired cached response was served, either while it
+	// is refreshed in the background or because the API failed.
+	CacheStale = "STALE"
+)
+
+// CacheStatus returns the cache outcome for a response returned by
+// Client.Do, or "" if the request did not go through the cache.
+func CacheStatus(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	return resp.Header.Get(cacheStatusHeader)
+}
+
+// FromCache reports whether the body of resp was served from the cache.
+func FromCache(resp *http.Response) bool {
+	switch CacheStatus(resp) {
+	case CacheHit, CacheRevalidated, CacheStale:
+		return true
+	}
+	return false
+}
+
+// cacheStage serves GET requests from the response cache and stores
+// successful JSON responses in it. Mutating requests evict the entries they
+// make stale; see invalidateAfterWrite. Expired entries carrying an ETag or
+// Last-Modified validator are revalidated with a conditional request.
+func (c *Client) cacheStage(next Doer) Doer {
+	if c.cache == nil {
+		return next
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			resp, err := next.Do(req)
+			if isMutation(req.Method) {
+				c.invalidateAfterWrite(req.Method, c.endpointPath(req.URL))
+			}
+			return resp, err
+		}
+		ttl := c.cacheTTL.For(c.endpointPath(req.URL))
+		if ttl <= 0 {
+			return next.Do(req)
+		}
+		key := CacheKey(req)
+		if cacheBypassed(req) {
+			c.logCache("Bypassing cache", req)
+			resp, err := next.Do(req)
+			return c.storeResponse(req, key, ttl, nil, resp, err)
+		}
+		now := time.Now()
+		cached, ok := c.cache.Get(key)
+		if !ok {
+			cached = nil
+		}
+		if cached != nil && cached.Fresh(now) {
+			c.logCache("Cache hit", req)
+			return cachedResponse(req, cached.Body, CacheHit), nil
+		}
+		if cached != nil && c.staleWhileRevalidate > 0 && now.Before(cached.freshUntil().Add(c.staleWhileRevalidate)) {
+			c.logCache("Serving stale response while revalidating", req)
+			c.revalidate(next, req, key, ttl, cached)
+			return cachedResponse(req, cached.Body, CacheStale), nil
+		}
+		resp, err := next.Do(conditionalRequest(req, cached))
+		return c.storeResponse(req, key, ttl, cached, resp, err)
+	})
+}
+
+// storeResponse updates the cache from the API's answer to req and returns
+// the response the caller should see. cached is the previous entry, if any.
+func (c *Client) storeResponse(req *http.Request, key string, ttl time.Duration, cached *CacheEntry, resp *http.Response, err error) (*http.Response, error) {
+	if cached != nil && c.serveStaleOnError(req, cached, resp, err) {
+		discardBody(resp)
+		c.logCache("Serving stale response after API failure", req)
+		return cachedResponse(req, cached.Body, CacheStale), nil
+	}
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		discardBody(resp)
+		header := resp.Header.Clone()
+		if header.Get("ETag") == "" {
+			header.Set("ETag", cached.ETag)
+		}
+		if header.Get("Last-Modified") == "" {
+			header.Set("Last-Modified", cached.LastModified)
+		}
+		c.cache.Set(key, c.newCacheEntry(cached.Body, header, ttl))
+		c.logCache("Cache revalidated", req)
+		return cachedResponse(req, cached.Body, CacheRevalidated), nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if errClose := resp.Body.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading response for cache: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if json.Valid(body) {
+		c.cache.Set(key, c.newCacheEntry(body, resp.Header, ttl))
+	}
+	resp.Header.Set(cacheStatusHeader, CacheMiss)
+	return resp, nil
+}
+
+// serveStaleOnError reports whether a failed request should be answered
+// with the stale entry instead, per WithStaleIfError.
+func (c *Client) serveStaleOnError(req *http.Request, cached *CacheEntry, resp *http.Response, err error) bool {
+	if c.staleIfError <= 0 || req.Context().Err() != nil {
+		return false
+	}
+	if err == nil && resp.StatusCode < 500 {
+		return false
+	}
+	return time.Now().Before(cached.freshUntil().Add(c.staleIfError))
+}
+
+// revalidate refreshes a stale entry in the background. Only one refresh
+// per key runs at a time.
+func (c *Client) revalidate(next Doer, req *http.Request, key string, ttl time.Duration, cached *CacheEntry) {
+	if _, busy := c.revalidating.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+	bg := conditionalRequest(req.Clone(context.WithoutCancel(req.Context())), cached)
+	go func() {
+		defer c.revalidating.Delete(key)
+		resp, err := next.Do(bg)
+		resp, err = c.storeResponse(bg, key, ttl, cached, resp, err)
+		if err != nil {
+			if log := c.requestLogger(bg); log != nil {
+				log.Warn("Background revalidation failed", logging.String("url", bg.URL.String()), logging.Error("error", err))
+			}
+			return
+		}
+		discardBody(resp)
+	}()
+}
+
+// newCacheEntry builds an entry that is fresh for ttl. Entries are retained
+// past that for the stale-while-revalidate and stale-if-error windows, and
+// entries with validators for at least another ttl so they can be
+// revalidated instead of refetched.
+func (c *Client) newCacheEntry(body []byte, header http.Header, ttl time.Duration) *CacheEntry {
+	now := time.Now()
+	entry := &CacheEntry{
+		Body:         body,
+		StoredAt:     now,
+		FreshUntil:   now.Add(ttl),
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	retain := max(c.staleWhileRevalidate, c.staleIfError)
+	if (entry.ETag != "" || entry.LastModified != "") && retain < ttl {
+		retain = ttl
+	}
+	entry.ExpiresAt = entry.FreshUntil.Add(retain)
+	return entry
+}
+
+// conditionalRequest adds If-None-Match and If-Modified-Since validators
+// from cached to a copy of req.
+func conditionalRequest(req *http.Request, cached *CacheEntry) *http.Request {
+	if cached == nil || (cached.ETag == "" && cached.LastModified == "") {
+		return req
+	}
+	req = req.Clone(req.Context())
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+	return req
+}
+
+// cachedResponse builds a synthetic 200 response for a cached body.
+func cachedResponse(req *http.Request, body []byte, status string) *http.Response {
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	header.Set(cacheStatusHeader, status)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// discardBody drains and closes a response body so the connection can be reused.
+func discardBody(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+func (c *Client) logCache(msg string, req *http.Request) {
+	if log := c.requestLogger(req); log != nil {
+		log.Debug(msg, logging.String("url", req.URL.String()))
+	}
+}
@@ -0,0 +1,495 @@
+package huntress
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func entry(body string, ttl time.Duration) *CacheEntry {
+	now := time.Now()
+	return &CacheEntry{Body: []byte(body), StoredAt: now, ExpiresAt: now.Add(ttl)}
+}
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRUCache(2, 0)
+	c.Set("a", entry("1", time.Minute))
+	c.Set("b", entry("2", time.Minute))
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set("c", entry("3", time.Minute))
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to survive as most recently used")
+	}
+	stats := c.Stats()
+	if stats.Entries != 2 || stats.Evictions != 1 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLRUCache_BoundsBytes(t *testing.T) {
+	c := NewLRUCache(0, 10)
+	c.Set("a", entry("1234", time.Minute)) // 5 bytes
+	c.Set("b", entry("1234", time.Minute)) // 10 bytes
+	c.Set("c", entry("1234", time.Minute)) // evicts a
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to be evicted")
+	}
+	if got := c.Stats().Bytes; got != 10 {
+		t.Errorf("expected 10 bytes, got %d", got)
+	}
+	c.Set("huge", entry("0123456789", time.Minute))
+	if _, ok := c.Get("huge"); ok {
+		t.Error("expected an oversized entry not to be stored")
+	}
+}
+
+func TestLRUCache_DropsExpired(t *testing.T) {
+	c := NewLRUCache(0, 0)
+	c.Set("a", entry("1", -time.Second))
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected expired entry to miss")
+	}
+	if stats := c.Stats(); stats.Entries != 0 || stats.Evictions != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestDiskCache_SurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+	c.Set("GET:/organizations/1", entry(`{"id":"1"}`, time.Minute))
+	c.Set("GET:/expired", entry(`{}`, -time.Second))
+	if stats := c.Stats(); stats.Entries != 1 || stats.Evictions != 1 {
+		t.Errorf("expected the expired entry to be swept on Set, got %+v", stats)
+	}
+
+	reopened, err := NewDiskCache(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+	got, ok := reopened.Get("GET:/organizations/1")
+	if !ok || string(got.Body) != `{"id":"1"}` {
+		t.Fatalf("expected entry after reopen, got %v %v", got, ok)
+	}
+	if _, ok := reopened.Get("GET:/expired"); ok {
+		t.Error("expected expired entry to miss")
+	}
+	reopened.Delete("GET:/organizations/1")
+	if _, ok := reopened.Get("GET:/organizations/1"); ok {
+		t.Error("expected deleted entry to miss")
+	}
+	if stats := reopened.Stats(); stats.Entries != 0 || stats.Hits != 1 || stats.Misses != 2 || stats.Evictions != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestDiskCache_Bounds(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, 2, 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+	c.Set("a", entry("1", time.Minute))
+	c.Set("b", entry("2", time.Minute))
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set("c", entry("3", time.Minute))
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to survive as most recently used")
+	}
+
+	// Expired entries are swept even if never read again
+	c.Set("short", entry("4", 20*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	c.Set("d", entry("5", time.Minute))
+	if files, _ := filepath.Glob(filepath.Join(dir, "*"+diskCacheExt)); len(files) != 2 {
+		t.Errorf("expected 2 entry files on disk, found %d", len(files))
+	}
+
+	small, err := NewDiskCache(dir, 0, 200)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+	if stats := small.Stats(); stats.Entries != 1 || stats.Bytes > 200 {
+		t.Errorf("expected reopening with a byte bound to trim to 1 entry, got %+v", stats)
+	}
+	small.Set("big", entry(strings.Repeat("x", 300), time.Minute))
+	if _, ok := small.Get("big"); ok {
+		t.Error("expected an entry over the byte bound not to be stored")
+	}
+}
+
+func TestNopCache(t *testing.T) {
+	c := NewNopCache()
+	c.Set("a", entry("1", time.Minute))
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected NopCache to miss")
+	}
+	if stats := c.Stats(); stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCacheTTL_For(t *testing.T) {
+	ttl := CacheTTL{
+		Default: time.Minute,
+		Paths: map[string]time.Duration{
+			"/organizations":         time.Hour,
+			"/organizations/1/users": 0,
+			"/incidents":             5 * time.Second,
+		},
+	}
+	tests := map[string]time.Duration{
+		"/organizations":         time.Hour,
+		"/organizations/2":       time.Hour,
+		"/organizations/1/users": 0,
+		"/incidents/9":           5 * time.Second,
+		"/incidentsx":            time.Minute,
+		"/agents":                time.Minute,
+	}
+	for path, want := range tests {
+		if got := ttl.For(path); got != want {
+			t.Errorf("For(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestClient_CachePathTTL(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if _, err := w.Write([]byte(`{"id":"1"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	store := NewLRUCache(10, 0)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL+"/v1"),
+		WithCacheStore(store),
+		WithCachePathTTL("/incidents", 0),
+	)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, _ = client.Organization.Get(ctx, "1")
+		_, _ = client.Incident.Get(ctx, "1")
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("expected 3 upstream calls (organization cached, incident not), got %d", got)
+	}
+	if stats := client.CacheStats(); stats.Entries != 1 || stats.Hits != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// newValidatorServer serves /organizations/1 with an ETag and answers
+// matching If-None-Match requests with 304. fail makes it return 503.
+func newValidatorServer(t *testing.T, fail *atomic.Bool) (*httptest.Server, *int32, *int32) {
+	t.Helper()
+	var calls, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if fail != nil && fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if _, err := w.Write([]byte(`{"id":"1","name":"Acme"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &notModified
+}
+
+func TestClient_Cache_HitReturnsSuccess(t *testing.T) {
+	srv, calls, _ := newValidatorServer(t, nil)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithCacheTTL(time.Minute))
+	for i := 0; i < 3; i++ {
+		org, err := client.Organization.Get(context.Background(), "1")
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		if org.Name != "Acme" {
+			t.Errorf("call %d: expected Acme, got %q", i, org.Name)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+}
+
+func TestClient_Cache_RevalidatesWithETag(t *testing.T) {
+	srv, calls, notModified := newValidatorServer(t, nil)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithCacheTTL(20*time.Millisecond))
+	ctx := context.Background()
+
+	req, _ := client.NewRequest(ctx, http.MethodGet, "/organizations/1", nil)
+	if _, err := client.Do(ctx, req, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	var org Organization
+	req, _ = client.NewRequest(ctx, http.MethodGet, "/organizations/1", nil)
+	resp, err := client.Do(ctx, req, &org)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if CacheStatus(resp) != CacheRevalidated || org.Name != "Acme" {
+		t.Errorf("expected revalidated cached body, got status %q and %+v", CacheStatus(resp), org)
+	}
+	if atomic.LoadInt32(calls) != 2 || atomic.LoadInt32(notModified) != 1 {
+		t.Errorf("expected one conditional request, got %d calls and %d 304s", atomic.LoadInt32(calls), atomic.LoadInt32(notModified))
+	}
+}
+
+func TestClient_Cache_StaleIfError(t *testing.T) {
+	var fail atomic.Bool
+	srv, _, _ := newValidatorServer(t, &fail)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithCacheTTL(10*time.Millisecond),
+		WithStaleIfError(time.Minute),
+	)
+	ctx := context.Background()
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	fail.Store(true)
+
+	org, err := client.Organization.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("expected stale response, got %v", err)
+	}
+	if org.Name != "Acme" {
+		t.Errorf("expected stale Acme, got %q", org.Name)
+	}
+}
+
+func TestClient_Cache_StaleWhileRevalidate(t *testing.T) {
+	srv, calls, _ := newValidatorServer(t, nil)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithCacheTTL(10*time.Millisecond),
+		WithStaleWhileRevalidate(time.Minute),
+	)
+	ctx := context.Background()
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	req, _ := client.NewRequest(ctx, http.MethodGet, "/organizations/1", nil)
+	resp, err := client.Do(ctx, req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if CacheStatus(resp) != CacheStale {
+		t.Errorf("expected stale response, got %q", CacheStatus(resp))
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(calls) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("expected a background revalidation, got %d calls", got)
+	}
+}
+
+func TestClient_Cache_StaleWhileRevalidateRefreshesOnNotModified(t *testing.T) {
+	srv, calls, notModified := newValidatorServer(t, nil)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithCacheTTL(200*time.Millisecond),
+		WithStaleWhileRevalidate(time.Minute),
+	)
+	ctx := context.Background()
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	req, _ := client.NewRequest(ctx, http.MethodGet, "/organizations/1", nil)
+	resp, err := client.Do(ctx, req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if CacheStatus(resp) != CacheStale {
+		t.Fatalf("expected stale response, got %q", CacheStatus(resp))
+	}
+	waitFor(t, func() bool {
+		_, busy := client.revalidating.Load(CacheKey(req))
+		return !busy
+	})
+	if got := atomic.LoadInt32(notModified); got != 1 {
+		t.Fatalf("expected the background revalidation to get a 304, got %d", got)
+	}
+
+	req, _ = client.NewRequest(ctx, http.MethodGet, "/organizations/1", nil)
+	resp, err = client.Do(ctx, req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if CacheStatus(resp) != CacheHit {
+		t.Errorf("expected a fresh cache hit after the 304, got %q", CacheStatus(resp))
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", got)
+	}
+}
+
+func TestClient_Cache_KeysOnRequestHeaders(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if _, err := w.Write([]byte(`{"id":"1","name":"` + r.Header.Get("Accept-Language") + `"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithCacheTTL(time.Minute))
+
+	get := func(lang string) string {
+		t.Helper()
+		ctx := WithRequestOptions(context.Background(), RequestHeader("Accept-Language", lang), RequestID("id-"+lang))
+		org, err := client.Organization.Get(ctx, "1")
+		if err != nil {
+			t.Fatalf("Get(%s): %v", lang, err)
+		}
+		return org.Name
+	}
+	for _, lang := range []string{"de", "fr", "de", "fr"} {
+		if got := get(lang); got != lang {
+			t.Errorf("Get(%s) returned the %q representation", lang, got)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 upstream calls, one per header value, got %d", got)
+	}
+	if n := client.InvalidateCache("/organizations/1"); n != 2 {
+		t.Errorf("InvalidateCache removed %d entries, want both representations", n)
+	}
+}
+
+func newCountingServer(t *testing.T) (*httptest.Server, *sync.Map) {
+	t.Helper()
+	var calls sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := calls.LoadOrStore(r.Method+" "+r.URL.Path, new(int32))
+		atomic.AddInt32(n.(*int32), 1)
+		body := `{"id":"1"}`
+		if strings.Count(r.URL.Path, "/") < 3 {
+			body = `[{"id":"1"}]`
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func callCount(calls *sync.Map, key string) int32 {
+	n, ok := calls.Load(key)
+	if !ok {
+		return 0
+	}
+	return atomic.LoadInt32(n.(*int32))
+}
+
+func TestClient_Cache_InvalidatesOnMutation(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithCacheTTL(time.Minute))
+	ctx := context.Background()
+	warm := func() {
+		_, _ = client.Organization.Get(ctx, "1")
+		_, _ = client.Organization.Get(ctx, "2")
+		_, _, _ = client.Organization.List(ctx, &ListOrganizationsParams{ListParams: ListParams{Page: 1}})
+	}
+	warm()
+	if _, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "Renamed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	warm()
+
+	if got := callCount(calls, "GET /v1/organizations/1"); got != 2 {
+		t.Errorf("expected updated organization to be refetched, got %d calls", got)
+	}
+	if got := callCount(calls, "GET /v1/organizations"); got != 2 {
+		t.Errorf("expected organization listing to be refetched, got %d calls", got)
+	}
+	if got := callCount(calls, "GET /v1/organizations/2"); got != 1 {
+		t.Errorf("expected unrelated organization to stay cached, got %d calls", got)
+	}
+}
+
+func TestClient_Cache_CustomInvalidationRule(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithCacheTTL(time.Minute),
+		WithCacheInvalidation(func(_ string, path string) []string {
+			if strings.HasPrefix(path, "/organizations/") {
+				return []string{"/agents"}
+			}
+			return nil
+		}),
+	)
+	ctx := context.Background()
+	_, _, _ = client.Agent.List(ctx, nil)
+	if err := client.Organization.Delete(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, _ = client.Agent.List(ctx, nil)
+	if got := callCount(calls, "GET /agents"); got != 2 {
+		t.Errorf("expected agent listing to be evicted by the custom rule, got %d calls", got)
+	}
+}
+
+func TestClient_InvalidateCache(t *testing.T) {
+	srv, _ := newCountingServer(t)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithCacheTTL(time.Minute))
+	ctx := context.Background()
+	for _, id := range []string{"1", "10"} {
+		_, _ = client.Organization.Get(ctx, id)
+	}
+	_, _ = client.Agent.Get(ctx, "1")
+
+	if n := client.InvalidateCache("/organizations/1"); n != 1 {
+		t.Errorf("expected 1 entry evicted, got %d", n)
+	}
+	if n := client.InvalidateCache(""); n != 2 {
+		t.Errorf("expected the remaining 2 entries evicted, got %d", n)
+	}
+}
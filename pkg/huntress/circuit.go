@@ -0,0 +1,333 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+)
+
+// Circuit breaker defaults.
+const (
+	DefaultCircuitFailureRate = 0.5
+	DefaultCircuitMinRequests = 10
+	DefaultCircuitWindow      = 30 * time.Second
+	DefaultCircuitOpenTimeout = 30 * time.Second
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	// CircuitClosed lets requests through and counts their failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests immediately with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a few probe requests through to test recovery.
+	CircuitHalfOpen
+)
+
+// String returns the state's name.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// CircuitBreakerConfig configures the circuit breaker enabled with
+// WithCircuitBreaker. Zero fields use the defaults.
+type CircuitBreakerConfig struct {
+	// FailureRate is the share of 5xx responses, timeouts and transport
+	// errors (refused or reset connections, DNS failures), between 0 and 1,
+	// that opens a group's circuit. Defaults to 0.5.
+	FailureRate float64
+	// MinRequests is how many requests a group must see within Window
+	// before FailureRate is considered. Defaults to 10.
+	MinRequests int
+	// Window is how long failures are counted before the counts reset.
+	// Defaults to 30 seconds.
+	Window time.Duration
+	// OpenTimeout is how long a circuit stays open before letting probe
+	// requests through. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is how many probes must succeed to close the
+	// circuit again. Defaults to 1.
+	HalfOpenRequests int
+	// Groups lists endpoint path prefixes that share a circuit, such as
+	// "/reports". Paths matching no prefix are grouped by their first
+	// segment, so "/agents/42" and "/agents" share the "/agents" circuit.
+	Groups []string
+	// OnStateChange, if set, is called after a group's circuit changes
+	// state. It must not block.
+	OnStateChange func(group string, from, to CircuitState)
+}
+
+// CircuitOpenError is returned without contacting the API while the circuit
+// for an endpoint group is open. It matches ErrCircuitOpen with errors.Is.
+type CircuitOpenError struct {
+	// Group is the endpoint group whose circuit is open
+	Group string
+	// RetryAfter is how long until the circuit lets a probe through
+	RetryAfter time.Duration
+}
+
+// ErrCircuitOpen matches every CircuitOpenError.
+var ErrCircuitOpen = &CircuitOpenError{}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	if e.Group == "" {
+		return "circuit breaker open"
+	}
+	return fmt.Sprintf("circuit breaker open for %s, retry after %s", e.Group, e.RetryAfter.Round(time.Second))
+}
+
+// Code returns the error code
+func (e *CircuitOpenError) Code() string {
+	return "CIRCUIT_OPEN"
+}
+
+// StatusCode returns the HTTP status code
+func (e *CircuitOpenError) StatusCode() int {
+	return 0 // The request was never sent
+}
+
+// Is reports whether target is a CircuitOpenError.
+func (e *CircuitOpenError) Is(target error) bool {
+	_, ok := target.(*CircuitOpenError)
+	return ok
+}
+
+// IsCircuitOpenError returns true if the error is a CircuitOpenError
+func IsCircuitOpenError(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// circuitBreaker tracks one circuit per endpoint group.
+type circuitBreaker struct {
+	cfg CircuitBreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is the state of one endpoint group.
+type circuit struct {
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int // probes in flight while half-open
+	successes   int // successful probes while half-open
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	if cfg.FailureRate <= 0 || cfg.FailureRate > 1 {
+		cfg.FailureRate = DefaultCircuitFailureRate
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = DefaultCircuitMinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultCircuitWindow
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultCircuitOpenTimeout
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &circuitBreaker{cfg: cfg, now: time.Now, circuits: make(map[string]*circuit)}
+}
+
+// group returns the endpoint group for path.
+func (b *circuitBreaker) group(path string) string {
+	best := ""
+	for _, prefix := range b.cfg.Groups {
+		if len(prefix) > len(best) && pathHasPrefix(path, prefix) {
+			best = prefix
+		}
+	}
+	if best != "" {
+		return best
+	}
+	seg := strings.TrimPrefix(path, "/")
+	if i := strings.IndexByte(seg, '/'); i >= 0 {
+		seg = seg[:i]
+	}
+	return "/" + seg
+}
+
+// state returns the current state of group's circuit.
+func (b *circuitBreaker) state(group string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[group]; ok {
+		if c.state == CircuitOpen && !b.now().Before(c.openedAt.Add(b.cfg.OpenTimeout)) {
+			return CircuitHalfOpen
+		}
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// allow reports whether a request to group may be sent. Requests admitted
+// as half-open probes must be reported with done.
+func (b *circuitBreaker) allow(group string) error {
+	b.mu.Lock()
+	c := b.circuit(group)
+	now := b.now()
+	var from CircuitState
+	changed := false
+	if c.state == CircuitOpen {
+		if wait := c.openedAt.Add(b.cfg.OpenTimeout).Sub(now); wait > 0 {
+			b.mu.Unlock()
+			return &CircuitOpenError{Group: group, RetryAfter: wait}
+		}
+		from, changed = c.state, true
+		c.state, c.probes, c.successes = CircuitHalfOpen, 0, 0
+	}
+	if c.state == CircuitHalfOpen {
+		if c.probes+c.successes >= b.cfg.HalfOpenRequests {
+			b.mu.Unlock()
+			b.notify(group, from, CircuitHalfOpen, changed)
+			return &CircuitOpenError{Group: group}
+		}
+		c.probes++
+	}
+	b.mu.Unlock()
+	b.notify(group, from, CircuitHalfOpen, changed)
+	return nil
+}
+
+// done records the outcome of a request admitted by allow.
+func (b *circuitBreaker) done(group string, failed bool) {
+	b.mu.Lock()
+	c := b.circuit(group)
+	now := b.now()
+	from := c.state
+	switch c.state {
+	case CircuitHalfOpen:
+		c.probes--
+		if failed {
+			b.open(c, now)
+			break
+		}
+		c.successes++
+		if c.successes >= b.cfg.HalfOpenRequests {
+			c.state = CircuitClosed
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+	case CircuitClosed:
+		if now.Sub(c.windowStart) >= b.cfg.Window {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= b.cfg.MinRequests && float64(c.failures)/float64(c.requests) >= b.cfg.FailureRate {
+			b.open(c, now)
+		}
+	}
+	to := c.state
+	b.mu.Unlock()
+	b.notify(group, from, to, from != to)
+}
+
+// release forgets a half-open probe that ended without an outcome.
+func (b *circuitBreaker) release(group string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.circuit(group); c.state == CircuitHalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+// circuit returns group's circuit, creating it closed. b.mu must be held.
+func (b *circuitBreaker) circuit(group string) *circuit {
+	c, ok := b.circuits[group]
+	if !ok {
+		c = &circuit{windowStart: b.now()}
+		b.circuits[group] = c
+	}
+	return c
+}
+
+// open trips c. b.mu must be held.
+func (b *circuitBreaker) open(c *circuit, now time.Time) {
+	c.state, c.openedAt = CircuitOpen, now
+	c.requests, c.failures, c.probes, c.successes = 0, 0, 0, 0
+}
+
+func (b *circuitBreaker) notify(group string, from, to CircuitState, changed bool) {
+	if changed && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(group, from, to)
+	}
+}
+
+// isCircuitFailure reports whether a response or error counts against the
+// circuit: 5xx responses, timeouts and transport errors such as refused or
+// reset connections and failed DNS lookups. Requests canceled by the caller
+// do not count.
+func isCircuitFailure(resp *http.Response, err error) bool {
+	if err == nil {
+		return resp != nil && resp.StatusCode >= http.StatusInternalServerError
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// circuitStage fails fast while the request's endpoint group has an open
+// circuit and records each outcome.
+func (c *Client) circuitStage(next Doer) Doer {
+	if c.breaker == nil {
+		return next
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		group := c.breaker.group(c.endpointPath(req.URL))
+		if err := c.breaker.allow(group); err != nil {
+			return nil, err
+		}
+		resp, err := next.Do(req)
+		if err != nil && errors.Is(err, context.Canceled) {
+			c.breaker.release(group)
+			return resp, err
+		}
+		c.breaker.done(group, isCircuitFailure(resp, err))
+		return resp, err
+	})
+}
+
+// logCircuitChange is installed as the breaker's state-change hook when the
+// client has a Logger.
+func (c *Client) logCircuitChange(group string, from, to CircuitState) {
+	fields := []logging.Field{logging.String("group", group), logging.String("from", from.String()), logging.String("to", to.String())}
+	log := c.logger()
+	if to == CircuitOpen {
+		log.Warn("Circuit breaker opened", fields...)
+		return
+	}
+	log.Info("Circuit breaker state changed", fields...)
+}
+
+// CircuitState returns the state of the circuit guarding path, such as
+// "/agents". It reports CircuitClosed when no circuit breaker is configured.
+func (c *Client) CircuitState(path string) CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.state(c.breaker.group(path))
+}
@@ -0,0 +1,185 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newFlakyServer fails with 503 while *failing is non-zero.
+func newFlakyServer(t *testing.T, failing *int32) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(failing) != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if _, err := w.Write([]byte(`{"id":"1"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestClient_CircuitBreaker_OpensAndRecovers(t *testing.T) {
+	failing := int32(1)
+	srv, calls := newFlakyServer(t, &failing)
+	logger := &recordingLogger{}
+	var changes []string
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithLogger(logger),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			MinRequests: 2,
+			OpenTimeout: time.Minute,
+			OnStateChange: func(group string, from, to CircuitState) {
+				changes = append(changes, group+":"+from.String()+"->"+to.String())
+			},
+		}))
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	client.breaker.now = clock.now
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Agent.Get(ctx, "1"); !IsAPIError(err) {
+			t.Fatalf("request %d: expected an API error, got %v", i, err)
+		}
+	}
+	if got := client.CircuitState("/agents/1"); got != CircuitOpen {
+		t.Fatalf("expected the circuit to open, got %s", got)
+	}
+
+	_, err := client.Agent.Get(ctx, "1")
+	var open *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &open) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if open.Group != "/agents" || open.RetryAfter != time.Minute {
+		t.Errorf("unexpected error details: %+v", open)
+	}
+	if !IsTemporary(err) {
+		t.Error("expected an open circuit to be temporary")
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("expected the open circuit not to call the API, got %d calls", got)
+	}
+	if _, err := client.Organization.Get(ctx, "1"); IsCircuitOpenError(err) {
+		t.Error("expected other endpoint groups to be unaffected")
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	clock.advance(time.Minute)
+	if got := client.CircuitState("/agents"); got != CircuitHalfOpen {
+		t.Fatalf("expected the circuit to be half-open, got %s", got)
+	}
+	if _, err := client.Agent.Get(ctx, "1"); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if got := client.CircuitState("/agents"); got != CircuitClosed {
+		t.Errorf("expected the circuit to close, got %s", got)
+	}
+
+	want := []string{"/agents:closed->open", "/agents:open->half-open", "/agents:half-open->closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("expected state changes %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d: expected %s, got %s", i, want[i], changes[i])
+		}
+	}
+	if got := logger.Entries("Circuit breaker opened"); len(got) != 1 || got[0].Level != "warn" || got[0].Fields["group"] != "/agents" {
+		t.Errorf("expected the opening to be logged as a warning, got %+v", got)
+	}
+}
+
+func TestClient_CircuitBreaker_OpensOnRefusedConnections(t *testing.T) {
+	var dials int32
+	httpClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(context.Context, string, string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		},
+	}}
+	client := New(WithCredentials("k", "s"), WithBaseURL("http://api.invalid"), WithHTTPClient(httpClient),
+		WithRetryConfig(0, time.Millisecond, time.Millisecond),
+		WithCircuitBreaker(CircuitBreakerConfig{MinRequests: 2, OpenTimeout: time.Minute}))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Agent.Get(ctx, "1"); err == nil || IsCircuitOpenError(err) {
+			t.Fatalf("request %d: expected a connection error, got %v", i, err)
+		}
+	}
+	if got := client.CircuitState("/agents"); got != CircuitOpen {
+		t.Fatalf("expected refused connections to open the circuit, got %s", got)
+	}
+	if _, err := client.Agent.Get(ctx, "1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := atomic.LoadInt32(&dials); got != 2 {
+		t.Errorf("expected the open circuit not to dial, got %d dials", got)
+	}
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{MinRequests: 1, OpenTimeout: time.Second})
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	b.now = clock.now
+
+	b.done("/reports", true)
+	clock.advance(time.Second)
+	if err := b.allow("/reports"); err != nil {
+		t.Fatalf("expected a probe to be allowed, got %v", err)
+	}
+	if err := b.allow("/reports"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected only one probe at a time, got %v", err)
+	}
+	b.done("/reports", true)
+	if got := b.state("/reports"); got != CircuitOpen {
+		t.Errorf("expected a failed probe to reopen the circuit, got %s", got)
+	}
+}
+
+func TestCircuitBreaker_Groups(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{Groups: []string{"/reports", "/reports/summary"}})
+	for path, want := range map[string]string{
+		"/agents/42":          "/agents",
+		"/reports/7/download": "/reports",
+		"/reports/summary/x":  "/reports/summary",
+		"/":                   "/",
+	} {
+		if got := b.group(path); got != want {
+			t.Errorf("group(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestIsCircuitFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"ok", &http.Response{StatusCode: http.StatusOK}, nil, false},
+		{"not found", &http.Response{StatusCode: http.StatusNotFound}, nil, false},
+		{"server error", &http.Response{StatusCode: http.StatusBadGateway}, nil, true},
+		{"timeout", nil, context.DeadlineExceeded, true},
+		{"refused", nil, errors.New("connection refused"), true},
+		{"canceled", nil, fmt.Errorf("request: %w", context.Canceled), false},
+	}
+	for _, tt := range tests {
+		if got := isCircuitFailure(tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
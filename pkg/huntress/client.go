@@ -6,12 +6,21 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"log/slog"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 
+	api "github.com/greysquirr3l/bishoujo-huntress/internal/adapters/api"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
 	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/redact"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 // BulkService provides bulk actions for agents and organizations.
@@ -26,6 +35,8 @@ type BulkService interface {
 type AuditLogService interface {
 	List(ctx context.Context, params *AuditLogListParams) ([]*AuditLog, *Pagination, error)
 	Get(ctx context.Context, id string) (*AuditLog, error)
+	// All iterates over every audit log matching params, fetching pages lazily
+	All(ctx context.Context, params *AuditLogListParams) iter.Seq2[*AuditLog, error]
 }
 
 // IntegrationService provides access to integrations.
@@ -53,8 +64,28 @@ type Client struct {
 	apiVersion  string
 	rateLimiter RateLimiter
 	Logger      logging.Logger
+	redactor    redact.Redactor // Applied to everything logged; see WithLogger
+	debug       bool            // Log request and response bodies; see WithDebug
 
-	cache *Cache // Optional: in-memory cache for GET requests
+	retrier            *retry.Retrier // Optional: retries for 429/5xx and transport errors
+	retryNonIdempotent bool
+	middleware         []Middleware // Optional: caller-supplied pipeline stages
+
+	cache    CacheStore // Optional: cache for GET requests
+	cacheTTL CacheTTL
+	// staleWhileRevalidate and staleIfError extend how long expired entries
+	// may be served; see WithStaleWhileRevalidate and WithStaleIfError
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+	revalidating         sync.Map
+	invalidationRules    []InvalidationRule
+	flights              *flightGroup    // Optional: coalesces identical in-flight GETs
+	breaker              *circuitBreaker // Optional: fails fast while an endpoint group is failing
+	versions             *versionStore   // Optional: versions of read resources for conditional updates
+	dryRun               bool
+	plan                 *DryRunPlan // Mutations intercepted in dry-run mode
+	metrics              Metrics     // Optional: receives a measurement per call
+	tracer               Tracer      // Optional: starts a span per call
 
 	// Services for interacting with different API parts
 	Account      AccountService
@@ -65,6 +96,8 @@ type Client struct {
 	Billing      BillingService
 	Webhook      WebhookService
 	AuditLog     AuditLogService
+	Integration  IntegrationService
+	Bulk         BulkService
 }
 
 // New creates a new Huntress API client
@@ -99,11 +132,62 @@ func New(opts ...Option) *Client {
 		apiVersion:  options.apiVersion,
 		rateLimiter: options.rateLimiter,
 		Logger:      options.logger,
+		redactor:    newLogRedactor(options.apiKey, options.apiSecret),
+		debug:       options.debug,
+		middleware:  options.middleware,
+		dryRun:      options.dryRun,
+		plan:        &DryRunPlan{},
+		metrics:     options.metrics,
+		tracer:      options.tracer,
+	}
+
+	// Redaction is not optional: every logger sees only redacted output
+	if client.Logger == nil && options.debug {
+		client.Logger = NewSlogLogger(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	client.Logger = client.logger()
+
+	// Enable retries if requested
+	if options.retryConfig != nil {
+		client.retrier = newRetrier(options.retryConfig)
+		client.retryNonIdempotent = options.retryConfig.RetryNonIdempotent
+	}
+
+	if options.circuitBreaker != nil {
+		cfg := *options.circuitBreaker
+		if client.Logger != nil {
+			onChange := cfg.OnStateChange
+			cfg.OnStateChange = func(group string, from, to CircuitState) {
+				client.logCircuitChange(group, from, to)
+				if onChange != nil {
+					onChange(group, from, to)
+				}
+			}
+		}
+		client.breaker = newCircuitBreaker(cfg)
+	}
+
+	if options.optimisticConcurrency {
+		client.versions = newVersionStore()
+	}
+
+	if options.coalesce {
+		client.flights = newFlightGroup()
 	}
 
 	// Enable response caching for GET requests if requested
-	if options.cacheTTL > 0 {
-		client.cache = NewCache(options.cacheTTL)
+	if options.cacheTTL > 0 || options.cacheStore != nil {
+		client.cache = options.cacheStore
+		if client.cache == nil {
+			client.cache = NewLRUCache(defaultCacheMaxEntries, defaultCacheMaxBytes)
+		}
+		client.cacheTTL = CacheTTL{Default: options.cacheTTL, Paths: options.cachePathTTLs}
+		client.staleWhileRevalidate = options.staleWhileRevalidate
+		client.staleIfError = options.staleIfError
+		client.invalidationRules = options.invalidationRules
+		if client.cacheTTL.Default <= 0 {
+			client.cacheTTL.Default = DefaultCacheTTL
+		}
 	}
 
 	// Initialize services
@@ -120,6 +204,11 @@ func New(opts ...Option) *Client {
 	auditlogRepo := newInternalAuditLogRepo(client)
 	client.AuditLog = &auditLogService{repo: auditlogRepo}
 
+	// Adapters share the client's pipeline, so retries, rate limits, caching,
+	// logging and errors behave the same as for the services above
+	client.Integration = &api.IntegrationRepository{Client: client.transport(), BaseURL: client.baseURL, APIKey: client.apiKey, APISecret: client.apiSecret}
+	client.Bulk = &api.BulkRepository{Client: client.transport(), BaseURL: client.baseURL, APIKey: client.apiKey, APISecret: client.apiSecret}
+
 	return client
 }
 
@@ -149,91 +238,135 @@ func (c *Client) NewRequest(ctx context.Context, method, path string, body inter
 	req.Header.Set("Accept", jsonMime)
 	req.Header.Set("Authorization", "Basic "+basicAuth(c.apiKey, c.apiSecret))
 	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set(RequestIDHeader, requestIDFor(ctx))
 
-	if c.Logger != nil {
-		c.Logger.Debug("Creating new request", logging.String("method", method), logging.String("url", url))
+	if log := c.requestLogger(req); log != nil {
+		log.Debug("Creating new request", logging.String("method", method), logging.String("url", url))
 	}
 
 	return req, nil
 }
 
-// Do sends an API request and returns the response.
+// Do sends an API request through the client's pipeline and decodes a
+// successful JSON response into v. Non-2xx responses are returned as typed
+// errors (see APIError and RateLimitError). RequestOptions attached to the
+// context with WithRequestOptions are applied first.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
-	// Response caching for GET requests
-	if c.cache != nil && req.Method == http.MethodGet && v != nil {
-		key := CacheKey(req)
-		if cached := c.cache.Get(key); cached != nil {
-			if err := json.Unmarshal(cached, v); err == nil {
-				if c.Logger != nil {
-					c.Logger.Debug("Cache hit", logging.String("url", req.URL.String()))
-				}
-				return nil, fmt.Errorf("response served from cache") // No HTTP response, but data is filled
-			}
-		}
+	if c.metrics != nil {
+		return c.observe(ctx, req, v)
 	}
-	if c.Logger != nil {
-		c.Logger.Debug("Sending request", logging.String("method", req.Method), logging.String("url", req.URL.String()))
+	return c.do(ctx, req, v)
+}
+
+// doRaw sends req through the pipeline like Do and returns the response
+// body undecoded, for endpoints that serve files rather than JSON, such as
+// report downloads.
+func (c *Client) doRaw(ctx context.Context, req *http.Request) ([]byte, error) {
+	resp, err := c.Do(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if errClose := resp.Body.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
+	return data, nil
+}
 
-	// Apply rate limiting if configured
-	if c.rateLimiter != nil {
-		if err := c.rateLimiter.Wait(ctx); err != nil {
-			return nil, fmt.Errorf("rate limit error: %w", err)
+// do implements Do.
+func (c *Client) do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	if ctx != nil && ctx != req.Context() {
+		req = req.WithContext(ctx)
+	}
+	req, cancel := applyRequestOptions(req)
+	defer cancel()
+	if c.dryRunEnabled(req) {
+		// Intercepted before the pipeline, so nothing is sent and the
+		// cache is not invalidated
+		resp, err := c.interceptDryRun(req)
+		if err != nil {
+			return nil, err
 		}
+		decodeDryRun(resp, v)
+		return resp, nil
+	}
+	req, err := c.prepareWrite(req)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.pipeline().Do(req)
 	if err != nil {
-		if c.Logger != nil {
-			c.Logger.Error("Request failed", logging.Error("error", err))
+		var open *CircuitOpenError
+		if errors.As(err, &open) {
+			return nil, open
 		}
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, newRequestError(req, err)
 	}
-	if resp != nil {
-		errClose := resp.Body.Close()
-		if errClose != nil {
-			return nil, fmt.Errorf("client doJSON: error closing response body: %w", errClose)
-		}
+	if resp.Request == nil {
+		resp.Request = req
 	}
 
-	if v == nil {
-		return resp, nil
+	// Buffer the body so it can be decoded here and still read by callers
+	log := c.requestLogger(req)
+	bodyBytes, err := io.ReadAll(resp.Body)
+	errClose := resp.Body.Close()
+	if err != nil {
+		if log != nil {
+			log.Error("Error reading response body", logging.Error("error", err))
+		}
+		return resp, fmt.Errorf("error reading response body: %w", err)
+	}
+	if errClose != nil {
+		return nil, fmt.Errorf("client doJSON: error closing response body: %w", errClose)
 	}
+	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	resp.ContentLength = int64(len(bodyBytes))
 
 	// Check for error responses
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return resp, fmt.Errorf("API error: status code %d, failed to read body: %w", resp.StatusCode, err)
+		if log != nil {
+			log.Warn("API error response", logging.Int("status", resp.StatusCode), logging.String("body", string(bodyBytes)))
 		}
-		if c.Logger != nil {
-			c.Logger.Warn("API error response", logging.Int("status", resp.StatusCode), logging.String("body", string(bodyBytes)))
+		apiErr := newAPIError(resp, bodyBytes)
+		if c.isConflict(req, resp.StatusCode) {
+			var typed *APIError
+			errors.As(apiErr, &typed)
+			conflict, err := c.currentState(req, typed)
+			if err != nil {
+				return resp, err
+			}
+			return resp, conflict
 		}
-		return resp, fmt.Errorf("API error: status code %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return resp, apiErr
 	}
+	c.recordVersion(req, resp, bodyBytes)
 
-	// Read and cache the response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		if c.Logger != nil {
-			c.Logger.Error("Error reading response body", logging.Error("error", err))
-		}
-		return resp, fmt.Errorf("error reading response body: %w", err)
+	if v == nil || len(bodyBytes) == 0 {
+		return resp, nil
 	}
+
 	if err := json.Unmarshal(bodyBytes, v); err != nil {
-		if c.Logger != nil {
-			c.Logger.Error("Error decoding response", logging.Error("error", err))
+		if log != nil {
+			log.Error("Error decoding response", logging.Error("error", err))
 		}
 		return resp, fmt.Errorf("error decoding response: %w", err)
 	}
-	// Store in cache
-	if c.cache != nil && req.Method == http.MethodGet {
-		key := CacheKey(req)
-		c.cache.Set(key, bodyBytes)
-	}
 	return resp, nil
 }
 
+// transport exposes the client's pipeline to the internal adapters. Like Do,
+// it buffers response bodies and reports non-2xx responses as typed errors,
+// returned together with the response.
+func (c *Client) transport() transport.Doer {
+	return transport.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		return c.Do(req.Context(), req, nil)
+	})
+}
+
 // basicAuth creates a basic auth header value from credentials
 func basicAuth(username, password string) string {
 	auth := username + ":" + password
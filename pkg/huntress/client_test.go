@@ -149,9 +149,12 @@ func TestClient_Do_cache(t *testing.T) {
 			t.Errorf("error closing response body: %v", err)
 		}
 	}
-	if err != nil && err.Error() != "response served from cache" {
+	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if FromCache(resp) {
+		t.Error("expected first response to come from the API")
+	}
 	// Second call should hit cache
 	req2, _ := client.NewRequest(context.Background(), "GET", "/cache", nil)
 	var out2 map[string]string
@@ -161,8 +164,11 @@ func TestClient_Do_cache(t *testing.T) {
 			t.Errorf("error closing response body: %v", err)
 		}
 	}
-	if err2 == nil || err2.Error() != "response served from cache" {
-		t.Errorf("expected cache error, got %v", err2)
+	if err2 != nil {
+		t.Fatalf("expected cache hit to succeed, got %v", err2)
+	}
+	if !FromCache(resp2) || CacheStatus(resp2) != CacheHit {
+		t.Errorf("expected cache hit, got status %q", CacheStatus(resp2))
 	}
 	if out2[fooKey] != barVal {
 		t.Errorf("expected foo=bar from cache, got %v", out2)
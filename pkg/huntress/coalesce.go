@@ -0,0 +1,157 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+)
+
+// CoalesceStats reports how many GET requests were coalesced.
+type CoalesceStats struct {
+	// Upstream is the number of coalescable GET requests sent to the API.
+	Upstream uint64
+	// Saved is the number of calls that shared another call's request
+	// instead of sending their own.
+	Saved uint64
+}
+
+// flightGroup tracks in-flight GET requests by cache key so that identical
+// concurrent requests share a single upstream call.
+type flightGroup struct {
+	mu       sync.Mutex
+	flights  map[string]*flight
+	upstream atomic.Uint64
+	saved    atomic.Uint64
+}
+
+// flight is one shared upstream call. The call runs on a context detached
+// from any single caller and is canceled only once every caller has given
+// up, so one caller's cancellation does not fail the others.
+type flight struct {
+	done      chan struct{}
+	waiters   int
+	cancel    context.CancelFunc
+	requestID string // the X-Request-ID of the request actually sent
+	resp      *http.Response
+	body      []byte
+	err       error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight)}
+}
+
+// coalesceStage shares one upstream call between identical concurrent GET
+// requests, keyed by CacheKey. Each caller receives its own copy of the
+// response; callers that join a call log the request ID it was sent with.
+func (c *Client) coalesceStage(next Doer) Doer {
+	if c.flights == nil {
+		return next
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet || cacheBypassed(req) {
+			return next.Do(req)
+		}
+		return c.flights.do(req, next, func(leaderID string) {
+			if log := c.requestLogger(req); log != nil {
+				log.Debug("Sharing in-flight request", logging.String("leader_request_id", leaderID))
+			}
+		})
+	})
+}
+
+// do sends req, or waits for an identical call in flight, calling joined
+// with that call's request ID.
+func (g *flightGroup) do(req *http.Request, next Doer, joined func(leaderID string)) (*http.Response, error) {
+	key := CacheKey(req)
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if ok {
+		f.waiters++
+		g.saved.Add(1)
+	} else {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel, requestID: req.Header.Get(RequestIDHeader)}
+		g.flights[key] = f
+		g.upstream.Add(1)
+		go g.run(key, f, req.Clone(ctx), next)
+	}
+	g.mu.Unlock()
+	if ok {
+		joined(f.requestID)
+	}
+
+	select {
+	case <-f.done:
+		if f.err != nil {
+			return nil, f.err
+		}
+		return f.response(req), nil
+	case <-req.Context().Done():
+		g.leave(key, f)
+		return nil, req.Context().Err()
+	}
+}
+
+// run performs the shared call and buffers its body for every waiter.
+func (g *flightGroup) run(key string, f *flight, req *http.Request, next Doer) {
+	defer f.cancel()
+	resp, err := next.Do(req)
+	if err == nil {
+		f.body, err = io.ReadAll(resp.Body)
+		if errClose := resp.Body.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			err = fmt.Errorf("reading coalesced response: %w", err)
+		}
+	}
+	f.resp, f.err = resp, err
+
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// leave removes a caller that gave up waiting and cancels the call when no
+// callers remain.
+func (g *flightGroup) leave(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	f.waiters--
+	if f.waiters > 0 {
+		return
+	}
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	f.cancel()
+}
+
+// response returns a copy of the shared response for one caller.
+func (f *flight) response(req *http.Request) *http.Response {
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(f.body))
+	resp.Request = req
+	return &resp
+}
+
+// CoalesceStats returns request coalescing counters, or zero stats when
+// coalescing is disabled.
+func (c *Client) CoalesceStats() CoalesceStats {
+	if c.flights == nil {
+		return CoalesceStats{}
+	}
+	return CoalesceStats{Upstream: c.flights.upstream.Load(), Saved: c.flights.saved.Load()}
+}
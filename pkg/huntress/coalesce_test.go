@@ -0,0 +1,145 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newBlockingServer answers every request with an organization once release
+// is closed.
+func newBlockingServer(t *testing.T, release <-chan struct{}) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		if _, err := w.Write([]byte(`{"id":"1","name":"Acme"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_Coalesce_SharesInFlightGET(t *testing.T) {
+	release := make(chan struct{})
+	srv, calls := newBlockingServer(t, release)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithRequestCoalescing(true))
+
+	const callers = 8
+	var wg sync.WaitGroup
+	orgs := make([]*Organization, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			orgs[i], errs[i] = client.Organization.Get(context.Background(), "1")
+		}(i)
+	}
+	waitFor(t, func() bool { return client.CoalesceStats().Saved == callers-1 })
+	close(release)
+	wg.Wait()
+
+	for i := range orgs {
+		if errs[i] != nil || orgs[i] == nil || orgs[i].Name != "Acme" {
+			t.Fatalf("caller %d: unexpected result %+v, %v", i, orgs[i], errs[i])
+		}
+	}
+	if orgs[0] == orgs[1] {
+		t.Error("expected each caller to decode its own copy")
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+	if stats := client.CoalesceStats(); stats.Upstream != 1 || stats.Saved != callers-1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestClient_Coalesce_CallerCancelDoesNotFailOthers(t *testing.T) {
+	release := make(chan struct{})
+	srv, _ := newBlockingServer(t, release)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithRequestCoalescing(true))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.Organization.Get(ctx, "1")
+		leaderErr <- err
+	}()
+	waitFor(t, func() bool { return client.CoalesceStats().Upstream == 1 })
+
+	follower := make(chan error, 1)
+	go func() {
+		_, err := client.Organization.Get(context.Background(), "1")
+		follower <- err
+	}()
+	waitFor(t, func() bool { return client.CoalesceStats().Saved == 1 })
+
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled caller to see context.Canceled, got %v", err)
+	}
+	close(release)
+	if err := <-follower; err != nil {
+		t.Errorf("expected the other caller to succeed, got %v", err)
+	}
+}
+
+func TestClient_Coalesce_KeysOnRequestHeaders(t *testing.T) {
+	release := make(chan struct{})
+	srv, calls := newBlockingServer(t, release)
+	logger := &recordingLogger{}
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithRequestCoalescing(true), WithLogger(logger))
+
+	get := func(ctx context.Context, done chan<- error) {
+		_, err := client.Organization.Get(ctx, "1")
+		done <- err
+	}
+	done := make(chan error, 3)
+	go get(WithRequestOptions(context.Background(), RequestHeader("Accept-Language", "de"), RequestID("leader")), done)
+	waitFor(t, func() bool { return client.CoalesceStats().Upstream == 1 })
+	go get(WithRequestOptions(context.Background(), RequestHeader("Accept-Language", "fr")), done)
+	waitFor(t, func() bool { return client.CoalesceStats().Upstream == 2 })
+	go get(WithRequestOptions(context.Background(), RequestHeader("Accept-Language", "de"), RequestID("follower")), done)
+	waitFor(t, func() bool { return client.CoalesceStats().Saved == 1 })
+	close(release)
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("expected 2 upstream calls, one per header value, got %d", got)
+	}
+	joined := logger.Entries("Sharing in-flight request")
+	if len(joined) != 1 || joined[0].Fields["request_id"] != "follower" || joined[0].Fields["leader_request_id"] != "leader" {
+		t.Errorf("join log lines = %+v, want the follower citing the leader's request ID", joined)
+	}
+}
+
+func TestClient_Coalesce_Disabled(t *testing.T) {
+	client := New(WithCredentials("k", "s"))
+	if stats := client.CoalesceStats(); stats != (CoalesceStats{}) {
+		t.Errorf("expected zero stats, got %+v", stats)
+	}
+}
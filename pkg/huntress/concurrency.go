@@ -0,0 +1,275 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultConflictRetries is the number of attempts RetryOnConflict makes
+// when none is given.
+const DefaultConflictRetries = 5
+
+// maxTrackedVersions bounds how many resources the client remembers
+// versions for.
+const maxTrackedVersions = 10000
+
+// ConflictError is returned when an update was rejected because the
+// resource changed since it was last read. It matches ErrConflict with
+// errors.Is and carries the resource's current state.
+type ConflictError struct {
+	// Path is the API path of the resource, such as "/organizations/42"
+	Path string
+	// Current is the resource's current JSON representation, if it could
+	// be fetched
+	Current []byte
+	// ETag and UpdatedAt identify the current version, when known
+	ETag      string
+	UpdatedAt time.Time
+
+	api *APIError
+}
+
+// ErrConflict matches every ConflictError.
+var ErrConflict = &ConflictError{}
+
+// Error implements the error interface
+func (e *ConflictError) Error() string {
+	if e.Path == "" {
+		return "conflict: resource was modified"
+	}
+	return fmt.Sprintf("conflict: %s was modified since it was last read", e.Path)
+}
+
+// Code returns the error code
+func (e *ConflictError) Code() string {
+	return "CONFLICT"
+}
+
+// StatusCode returns the HTTP status code
+func (e *ConflictError) StatusCode() int {
+	if e.api != nil {
+		return e.api.StatusCode()
+	}
+	return http.StatusConflict
+}
+
+// Is reports whether target is a ConflictError.
+func (e *ConflictError) Is(target error) bool {
+	_, ok := target.(*ConflictError)
+	return ok
+}
+
+// Unwrap returns the API's error response, if the server reported the
+// conflict.
+func (e *ConflictError) Unwrap() error {
+	if e.api == nil {
+		return nil
+	}
+	return e.api
+}
+
+// DecodeCurrent decodes the resource's current state into v.
+func (e *ConflictError) DecodeCurrent(v interface{}) error {
+	if len(e.Current) == 0 {
+		return errors.New("conflict: current state unavailable")
+	}
+	if err := json.Unmarshal(e.Current, v); err != nil {
+		return fmt.Errorf("decoding current state: %w", err)
+	}
+	return nil
+}
+
+// IsConflictError returns true if the error is a ConflictError
+func IsConflictError(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// RetryOnConflict calls fn until it returns an error other than a
+// conflict, or attempts calls have been made. fn should re-read the
+// resource, apply its change and send the update, so each attempt works
+// from the latest version:
+//
+//	err := huntress.RetryOnConflict(ctx, 0, func(ctx context.Context) error {
+//		org, err := client.Organization.Get(ctx, id)
+//		if err != nil {
+//			return err
+//		}
+//		_, err = client.Organization.Update(ctx, id, changeName(org))
+//		return err
+//	})
+//
+// attempts <= 0 means DefaultConflictRetries.
+func RetryOnConflict(ctx context.Context, attempts int, fn func(ctx context.Context) error) error {
+	if attempts <= 0 {
+		attempts = DefaultConflictRetries
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("context error: %w", ctxErr)
+		}
+		if err = fn(ctx); !IsConflictError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// resourceVersion identifies the version of a resource the client last saw.
+type resourceVersion struct {
+	ETag      string
+	UpdatedAt time.Time
+}
+
+// versionStore remembers the versions of recently read resources.
+type versionStore struct {
+	mu       sync.Mutex
+	versions map[string]resourceVersion
+}
+
+func newVersionStore() *versionStore {
+	return &versionStore{versions: make(map[string]resourceVersion)}
+}
+
+func (s *versionStore) get(path string) (resourceVersion, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.versions[path]
+	return v, ok
+}
+
+func (s *versionStore) set(path string, v resourceVersion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.versions[path]; !ok && len(s.versions) >= maxTrackedVersions {
+		for k := range s.versions {
+			delete(s.versions, k)
+			break
+		}
+	}
+	s.versions[path] = v
+}
+
+func (s *versionStore) delete(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.versions, path)
+}
+
+// parseVersion reads the version of a single resource from a response.
+// ok is false when the response carries neither an ETag nor updated_at.
+func parseVersion(header http.Header, body []byte) (v resourceVersion, ok bool) {
+	v.ETag = header.Get("ETag")
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		var doc struct {
+			UpdatedAt time.Time `json:"updated_at"`
+		}
+		if json.Unmarshal(body, &doc) == nil {
+			v.UpdatedAt = doc.UpdatedAt
+		}
+	}
+	return v, v.ETag != "" || !v.UpdatedAt.IsZero()
+}
+
+// isVersionedWrite reports whether method replaces or patches a resource.
+func isVersionedWrite(method string) bool {
+	return method == http.MethodPatch || method == http.MethodPut
+}
+
+// conflictReadCtxKey marks the GET currentState sends. Its response is
+// not recorded as the version last read, so a blind resend of the rejected
+// update still conflicts until the caller reads the resource itself.
+type conflictReadCtxKey struct{}
+
+// recordVersion remembers the version in a successful response to req.
+func (c *Client) recordVersion(req *http.Request, resp *http.Response, body []byte) {
+	if c.versions == nil || req.Context().Value(conflictReadCtxKey{}) != nil {
+		return
+	}
+	path := c.endpointPath(req.URL)
+	switch {
+	case req.Method == http.MethodDelete:
+		c.versions.delete(path)
+	case req.Method == http.MethodGet || isVersionedWrite(req.Method):
+		if v, ok := parseVersion(resp.Header, body); ok {
+			c.versions.set(path, v)
+		}
+	}
+}
+
+// prepareWrite makes an update conditional on the version last read. With
+// an ETag it sends If-Match; with only updated_at it reads the resource
+// and fails with a ConflictError if it has changed.
+func (c *Client) prepareWrite(req *http.Request) (*http.Request, error) {
+	if c.versions == nil || !isVersionedWrite(req.Method) || req.Header.Get("If-Match") != "" {
+		return req, nil
+	}
+	path := c.endpointPath(req.URL)
+	known, ok := c.versions.get(path)
+	if !ok {
+		return req, nil
+	}
+	if known.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-Match", known.ETag)
+		return req, nil
+	}
+	conflict, err := c.currentState(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !conflict.UpdatedAt.IsZero() && !conflict.UpdatedAt.Equal(known.UpdatedAt) {
+		return nil, conflict
+	}
+	return req, nil
+}
+
+// isConflict reports whether a failed response to req means the resource
+// was modified by someone else.
+func (c *Client) isConflict(req *http.Request, status int) bool {
+	switch status {
+	case http.StatusPreconditionFailed:
+		return true
+	case http.StatusConflict:
+		return c.versions != nil && isVersionedWrite(req.Method)
+	}
+	return false
+}
+
+// currentState reads the resource req targets, bypassing the cache and the
+// version store, and returns it as a ConflictError. If the read fails the conflict is
+// reported without the current state, unless the caller's context ended.
+func (c *Client) currentState(req *http.Request, apiErr *APIError) (*ConflictError, error) {
+	conflict := &ConflictError{Path: c.endpointPath(req.URL), api: apiErr}
+	ctx := WithRequestOptions(context.WithValue(req.Context(), conflictReadCtxKey{}, true), BypassCache())
+	get := req.Clone(ctx)
+	get.Method = http.MethodGet
+	get.Body, get.GetBody, get.ContentLength = nil, nil, 0
+	get.Header.Del("If-Match")
+	get.Header.Del(IdempotencyKeyHeader)
+	resp, err := c.Do(ctx, get, nil)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("context error: %w", ctxErr)
+		}
+		return conflict, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return conflict, nil
+	}
+	conflict.Current = body
+	if v, ok := parseVersion(resp.Header, body); ok {
+		conflict.ETag, conflict.UpdatedAt = v.ETag, v.UpdatedAt
+	}
+	return conflict, nil
+}
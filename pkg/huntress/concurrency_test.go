@@ -0,0 +1,205 @@
+package huntress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// versionedOrgServer serves one organization whose version changes on
+// every write. With etags it honors If-Match; without, it only reports
+// updated_at.
+type versionedOrgServer struct {
+	mu      sync.Mutex
+	name    string
+	version int
+	etags   bool
+	patches int
+	ifMatch []string
+}
+
+func (s *versionedOrgServer) touch(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+	s.version++
+}
+
+func (s *versionedOrgServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Method == http.MethodPatch {
+		s.patches++
+		s.ifMatch = append(s.ifMatch, r.Header.Get("If-Match"))
+		if s.etags && r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		var body OrganizationUpdateParams
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body.Name != "" {
+			s.name = body.Name
+		}
+		s.version++
+		etag = fmt.Sprintf(`"v%d"`, s.version)
+	}
+	if s.etags {
+		w.Header().Set("ETag", etag)
+	}
+	updated := time.Unix(1_700_000_000, 0).Add(time.Duration(s.version) * time.Second).UTC()
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"id": "1", "name": s.name, "updated_at": updated}); err != nil {
+		panic(err)
+	}
+}
+
+func newVersionedClient(t *testing.T, etags bool) (*Client, *versionedOrgServer) {
+	t.Helper()
+	state := &versionedOrgServer{name: "Acme", etags: etags}
+	srv := httptest.NewServer(state)
+	t.Cleanup(srv.Close)
+	return New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithOptimisticConcurrency(true)), state
+}
+
+func TestOptimisticConcurrency_IfMatch(t *testing.T) {
+	client, state := newVersionedClient(t, true)
+	ctx := context.Background()
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state.touch("Acme Corp")
+
+	_, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "Acme Inc"})
+	var conflict *ConflictError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &conflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if state.ifMatch[0] != `"v0"` {
+		t.Errorf("expected If-Match with the ETag that was read, got %q", state.ifMatch[0])
+	}
+	var current Organization
+	if err := conflict.DecodeCurrent(&current); err != nil || current.Name != "Acme Corp" {
+		t.Errorf("expected the current server state, got %+v, %v", current, err)
+	}
+	if conflict.ETag != `"v1"` || conflict.StatusCode() != http.StatusPreconditionFailed {
+		t.Errorf("unexpected conflict details: %+v", conflict)
+	}
+
+	attempts := 0
+	err = RetryOnConflict(ctx, 3, func(ctx context.Context) error {
+		attempts++
+		org, err := client.Organization.Get(ctx, "1")
+		if err != nil {
+			return err
+		}
+		if attempts == 1 {
+			state.touch(org.Name + "!")
+		}
+		_, err = client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: org.Name + " Inc"})
+		return err
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("expected the second attempt to succeed, got %v after %d attempts", err, attempts)
+	}
+	if state.name != "Acme Corp! Inc" {
+		t.Errorf("expected the update to build on the latest state, got %q", state.name)
+	}
+}
+
+func TestOptimisticConcurrency_UpdatedAt(t *testing.T) {
+	client, state := newVersionedClient(t, false)
+	ctx := context.Background()
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "Mine"}); err != nil {
+		t.Fatalf("expected an unchanged resource to update, got %v", err)
+	}
+
+	state.touch("Theirs")
+	_, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "Mine again"})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if state.patches != 1 || state.name != "Theirs" {
+		t.Errorf("expected the stale update not to be sent, got %d patches and name %q", state.patches, state.name)
+	}
+	if conflict.UpdatedAt.IsZero() || conflict.Path != "/organizations/1" {
+		t.Errorf("unexpected conflict details: %+v", conflict)
+	}
+}
+
+func TestOptimisticConcurrency_BlindResendStillConflicts(t *testing.T) {
+	for _, etags := range []bool{true, false} {
+		client, state := newVersionedClient(t, etags)
+		ctx := context.Background()
+		if _, err := client.Organization.Get(ctx, "1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		state.touch("Theirs")
+
+		params := &OrganizationUpdateParams{Name: "Mine"}
+		for i := 0; i < 2; i++ {
+			if _, err := client.Organization.Update(ctx, "1", params); !errors.Is(err, ErrConflict) {
+				t.Fatalf("etags=%v, attempt %d: expected ErrConflict, got %v", etags, i, err)
+			}
+		}
+		if state.name != "Theirs" {
+			t.Errorf("etags=%v: expected the concurrent change to survive, got %q", etags, state.name)
+		}
+
+		if _, err := client.Organization.Get(ctx, "1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.Organization.Update(ctx, "1", params); err != nil {
+			t.Errorf("etags=%v: expected the update to succeed after a fresh read, got %v", etags, err)
+		}
+	}
+}
+
+func TestOptimisticConcurrency_Disabled(t *testing.T) {
+	state := &versionedOrgServer{name: "Acme", etags: true}
+	srv := httptest.NewServer(state)
+	defer srv.Close()
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+	ctx := context.Background()
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state.touch("Acme Corp")
+	if _, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "Acme Inc"}); err != nil {
+		t.Fatalf("expected updates to be unconditional by default, got %v", err)
+	}
+	if state.ifMatch[0] != "" {
+		t.Errorf("expected no If-Match header, got %q", state.ifMatch[0])
+	}
+}
+
+func TestRetryOnConflict_StopsOnOtherErrors(t *testing.T) {
+	errOther := errors.New("boom")
+	calls := 0
+	err := RetryOnConflict(context.Background(), 0, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return &ConflictError{Path: "/account"}
+		}
+		return errOther
+	})
+	if !errors.Is(err, errOther) || calls != 3 {
+		t.Errorf("expected to stop at the first non-conflict error, got %v after %d calls", err, calls)
+	}
+	calls = 0
+	err = RetryOnConflict(context.Background(), 2, func(context.Context) error {
+		calls++
+		return fmt.Errorf("update: %w", &ConflictError{})
+	})
+	if !IsConflictError(err) || calls != 2 {
+		t.Errorf("expected the last conflict after 2 calls, got %v after %d calls", err, calls)
+	}
+}
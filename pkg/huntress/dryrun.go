@@ -0,0 +1,180 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/redact"
+)
+
+// DryRunHeader is set to "true" on the synthetic responses returned for
+// requests intercepted in dry-run mode.
+const DryRunHeader = "X-Dry-Run"
+
+// PlannedRequest is a mutating request that dry-run mode intercepted.
+type PlannedRequest struct {
+	Method string
+	URL    string
+	// Body is the request body with secrets redacted
+	Body []byte
+	// Time is when the request was intercepted
+	Time time.Time
+}
+
+// String formats the request as "METHOD URL body".
+func (r PlannedRequest) String() string {
+	if len(r.Body) == 0 {
+		return r.Method + " " + r.URL
+	}
+	return fmt.Sprintf("%s %s %s", r.Method, r.URL, r.Body)
+}
+
+// DryRunPlan records the requests intercepted in dry-run mode, in the order
+// they were made. It is safe for concurrent use.
+type DryRunPlan struct {
+	mu       sync.Mutex
+	requests []PlannedRequest
+}
+
+// Requests returns a copy of the recorded requests.
+func (p *DryRunPlan) Requests() []PlannedRequest {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PlannedRequest(nil), p.requests...)
+}
+
+// Len returns the number of recorded requests.
+func (p *DryRunPlan) Len() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// Reset discards the recorded requests.
+func (p *DryRunPlan) Reset() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = nil
+}
+
+func (p *DryRunPlan) record(r PlannedRequest) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, r)
+}
+
+// Plan returns the requests intercepted in dry-run mode. See WithDryRun.
+func (c *Client) Plan() *DryRunPlan {
+	return c.plan
+}
+
+// dryRunEnabled reports whether req should be intercepted instead of sent.
+// A DryRun request option overrides the client's setting.
+func (c *Client) dryRunEnabled(req *http.Request) bool {
+	if !isMutation(req.Method) {
+		return false
+	}
+	if ro := requestOptionsFromContext(req.Context()); ro != nil && ro.dryRun != nil {
+		return *ro.dryRun
+	}
+	return c.dryRun
+}
+
+// interceptDryRun records req in the plan and returns a synthetic success
+// response without sending it. Writes with a JSON object body echo it back,
+// so the caller's result reflects the values it sent; other requests get
+// an empty body.
+func (c *Client) interceptDryRun(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("dry run: reading request body: %w", err)
+	}
+	planned := PlannedRequest{Method: req.Method, URL: redact.Redactor{}.URL(req.URL), Body: redact.Body(body), Time: time.Now()}
+	c.plan.record(planned)
+	if log := c.requestLogger(req); log != nil {
+		log.Info("Dry run: request not sent", logging.String("method", planned.Method), logging.String("url", planned.URL))
+	}
+
+	status := http.StatusOK
+	switch req.Method {
+	case http.MethodPost:
+		status = http.StatusCreated
+	case http.MethodDelete:
+		status = http.StatusNoContent
+	}
+	if status == http.StatusNoContent || !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		body = nil
+	}
+	header := http.Header{}
+	header.Set(DryRunHeader, "true")
+	if body != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// decodeDryRun fills v from a synthetic response's echoed body. The result
+// is best effort: the body is what the caller sent, which need not match the
+// shape of the resource the API would return, so decoding errors are
+// ignored.
+func decodeDryRun(resp *http.Response, v interface{}) {
+	if v == nil || resp.ContentLength == 0 {
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err == nil {
+		_ = json.Unmarshal(body, v)
+	}
+}
+
+// requestBody returns a copy of req's body, leaving req readable.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rc.Close() }()
+		return io.ReadAll(rc)
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,97 @@
+package huntress
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDryRun_InterceptsMutations(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithDryRun(true))
+	ctx := context.Background()
+
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	org, err := client.Organization.Create(ctx, &OrganizationCreateParams{
+		Name:     "Acme",
+		Settings: map[string]interface{}{"webhook_password": "hunter2"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if org.Name != "Acme" {
+		t.Errorf("synthetic result name = %q, want the name sent", org.Name)
+	}
+	if err := client.Organization.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if n := callCount(calls, "GET /v1/organizations/1"); n != 1 {
+		t.Errorf("GET sent %d times, want 1", n)
+	}
+	if n := callCount(calls, "POST /v1/organizations") + callCount(calls, "DELETE /v1/organizations/1"); n != 0 {
+		t.Errorf("%d mutations reached the server", n)
+	}
+
+	plan := client.Plan().Requests()
+	if len(plan) != 2 {
+		t.Fatalf("plan has %d requests, want 2: %v", len(plan), plan)
+	}
+	if plan[0].Method != http.MethodPost || plan[0].URL != srv.URL+"/v1/organizations" {
+		t.Errorf("plan[0] = %v", plan[0])
+	}
+	if want := `{"name":"Acme","settings":{"webhook_password":"[REDACTED]"}}`; string(plan[0].Body) != want {
+		t.Errorf("plan[0] body = %s, want %s", plan[0].Body, want)
+	}
+	if plan[1].Method != http.MethodDelete || len(plan[1].Body) != 0 {
+		t.Errorf("plan[1] = %v", plan[1])
+	}
+
+	client.Plan().Reset()
+	if client.Plan().Len() != 0 {
+		t.Error("Reset left requests in the plan")
+	}
+}
+
+func TestDryRun_DoesNotInvalidateCache(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithCacheTTL(time.Minute), WithDryRun(true))
+	ctx := context.Background()
+
+	_, _ = client.Organization.Get(ctx, "1")
+	if _, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "New"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	_, _ = client.Organization.Get(ctx, "1")
+
+	if n := callCount(calls, "GET /v1/organizations/1"); n != 1 {
+		t.Errorf("GET sent %d times, want 1 (cache should survive a dry-run update)", n)
+	}
+}
+
+func TestDryRun_PerCallOverride(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	ctx := context.Background()
+
+	live := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"))
+	if err := live.Organization.Delete(WithRequestOptions(ctx, DryRun(true)), "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n := callCount(calls, "DELETE /v1/organizations/1"); n != 0 {
+		t.Errorf("DryRun(true) call was sent")
+	}
+	if live.Plan().Len() != 1 {
+		t.Errorf("plan has %d requests, want 1", live.Plan().Len())
+	}
+
+	dry := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithDryRun(true))
+	if err := dry.Organization.Delete(WithRequestOptions(ctx, DryRun(false)), "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n := callCount(calls, "DELETE /v1/organizations/1"); n != 1 {
+		t.Errorf("DryRun(false) call sent %d times, want 1", n)
+	}
+}
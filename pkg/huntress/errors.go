@@ -2,9 +2,17 @@
 package huntress
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
 )
 
 // Error is the base error interface for all errors returned by this package
@@ -21,7 +29,13 @@ type InternalAPIError struct {
 	Code       string `json:"code"`
 	Message    string `json:"message"`
 	Details    string `json:"details,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
 	StatusCode int    `json:"-"` // Not part of the JSON response, set from HTTP status code
+	RawBody    []byte `json:"-"` // Unparsed response body, kept for diagnostics
+
+	// pathCode is the code derived from the status and request path, used
+	// to match sentinels when the API reports only a generic code
+	pathCode string
 }
 
 // Granular API error types for specific Huntress API error codes
@@ -37,10 +51,14 @@ var (
 )
 
 func (e *InternalAPIError) Error() string {
+	msg := fmt.Sprintf("[%s] %s", e.Code, e.Message)
 	if e.Details != "" {
-		return fmt.Sprintf("[%s] %s: %s", e.Code, e.Message, e.Details)
+		msg = fmt.Sprintf("%s: %s", msg, e.Details)
 	}
-	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request ID: %s)", msg, e.RequestID)
+	}
+	return msg
 }
 
 // InternalRateLimitError defines the structure for rate limit errors
@@ -54,14 +72,19 @@ func (e *InternalRateLimitError) Error() string {
 
 // InternalRequestError defines the structure for request errors
 type InternalRequestError struct {
-	Err error
+	Err       error
+	RequestID string // X-Request-ID sent with the failed request
 }
 
 func (e *InternalRequestError) Error() string {
-	if e.Err == nil {
-		return "request error"
+	msg := "request error"
+	if e.Err != nil {
+		msg = fmt.Sprintf("request error: %s", e.Err.Error())
 	}
-	return fmt.Sprintf("request error: %s", e.Err.Error())
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request ID: %s)", msg, e.RequestID)
+	}
+	return msg
 }
 
 // APIError represents an error returned by the Huntress API
@@ -109,6 +132,47 @@ func (e *APIError) Details() string {
 	return e.internal.Details
 }
 
+// RequestID returns the request ID reported by the API in its X-Request-Id
+// header or error body, or, when it reports none, the X-Request-ID the
+// client sent. Cite it when raising the failure with Huntress support.
+func (e *APIError) RequestID() string {
+	if e.internal == nil {
+		return ""
+	}
+	return e.internal.RequestID
+}
+
+// RawBody returns the unparsed response body
+func (e *APIError) RawBody() []byte {
+	if e.internal == nil {
+		return nil
+	}
+	return e.internal.RawBody
+}
+
+// Is reports whether e matches target. Two API errors match when both carry
+// an error code and the codes are equal, or otherwise when their HTTP status
+// codes are equal. When the API reports only a generic code such as
+// NOT_FOUND, the status and the resource in the request path decide
+// instead. This lets sentinels such as ErrOrgNotFound match real responses
+// through errors.Is.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok || t.internal == nil || e.internal == nil {
+		return false
+	}
+	if e.internal.Code != "" && t.internal.Code != "" {
+		if strings.EqualFold(e.internal.Code, t.internal.Code) {
+			return true
+		}
+		return e.internal.pathCode != "" &&
+			e.internal.StatusCode == t.internal.StatusCode &&
+			strings.EqualFold(e.internal.Code, defaultErrorCode(e.internal.StatusCode, "")) &&
+			strings.EqualFold(e.internal.pathCode, t.internal.Code)
+	}
+	return e.internal.StatusCode == t.internal.StatusCode
+}
+
 // IsNotFound returns true if the error is a 404 Not Found
 func (e *APIError) IsNotFound() bool {
 	return e.internal != nil && e.internal.StatusCode == http.StatusNotFound
@@ -127,6 +191,7 @@ func (e *APIError) IsForbidden() bool {
 // RateLimitError indicates that the API rate limit has been exceeded
 type RateLimitError struct {
 	internal *InternalRateLimitError
+	api      *APIError
 }
 
 // Error implements the error interface
@@ -155,6 +220,14 @@ func (e *RateLimitError) RetryAfter() int {
 	return e.internal.RetryAfter
 }
 
+// Unwrap returns the underlying APIError, if the error came from an API response
+func (e *RateLimitError) Unwrap() error {
+	if e.api == nil {
+		return nil
+	}
+	return e.api
+}
+
 // RequestError represents an error that occurred while making a request
 type RequestError struct {
 	internal *InternalRequestError
@@ -178,6 +251,14 @@ func (e *RequestError) StatusCode() int {
 	return 0 // No HTTP status for request errors
 }
 
+// RequestID returns the X-Request-ID sent with the failed request, if known
+func (e *RequestError) RequestID() string {
+	if e.internal == nil {
+		return ""
+	}
+	return e.internal.RequestID
+}
+
 // Unwrap returns the underlying error
 func (e *RequestError) Unwrap() error {
 	if e.internal == nil || e.internal.Err == nil {
@@ -246,6 +327,169 @@ func AsRateLimitError(err error) (*RateLimitError, bool) {
 	return nil, false
 }
 
+// IsTemporary returns true if the error reflects a transient condition that is
+// expected to clear on its own: rate limiting, an open circuit breaker, an
+// overloaded or unavailable server (408, 502, 503, 504), or a network timeout.
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsRateLimitError(err) || IsCircuitOpenError(err) {
+		return true
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		switch apiErr.StatusCode() {
+		case http.StatusRequestTimeout, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsRetryable returns true if repeating the request may succeed. In addition
+// to temporary errors this includes 500 responses and transport failures such
+// as connection resets. Errors caused by the caller canceling the context are
+// never retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if IsTemporary(err) {
+		return true
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.StatusCode() == http.StatusInternalServerError
+	}
+	return IsRequestError(err) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// newRequestError wraps a transport-level failure of req, which may be nil,
+// in a RequestError
+func newRequestError(req *http.Request, err error) *RequestError {
+	internal := &InternalRequestError{Err: err}
+	if req != nil {
+		internal.RequestID = req.Header.Get(RequestIDHeader)
+	}
+	return &RequestError{internal: internal}
+}
+
+// apiErrorBody is the JSON error envelope returned by the Huntress API.
+type apiErrorBody struct {
+	Code       string          `json:"code"`
+	Message    string          `json:"message"`
+	Error      string          `json:"error"`
+	Details    json.RawMessage `json:"details"`
+	RequestID  string          `json:"request_id"`
+	RetryAfter int             `json:"retry_after"`
+}
+
+// resourceNotFoundCodes maps API collections to the code used for a missing
+// member of that collection, so sentinels like ErrAgentNotFound match 404s
+// even when the API does not include a code in the body.
+var resourceNotFoundCodes = map[string]string{
+	"agents":        "AGENT_NOT_FOUND",
+	"organizations": "ORG_NOT_FOUND",
+	"webhooks":      "WEBHOOK_NOT_FOUND",
+	"integrations":  "INTEGRATION_NOT_FOUND",
+}
+
+// newAPIError builds a typed error from a non-2xx response. 429 responses
+// become a *RateLimitError wrapping the *APIError.
+func newAPIError(resp *http.Response, body []byte) error {
+	internal := &InternalAPIError{
+		StatusCode: resp.StatusCode,
+		RawBody:    body,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+
+	var parsed apiErrorBody
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		internal.Code = parsed.Code
+		internal.Message = parsed.Message
+		if internal.Message == "" {
+			internal.Message = parsed.Error
+		}
+		internal.Details = detailsString(parsed.Details)
+		if internal.RequestID == "" {
+			internal.RequestID = parsed.RequestID
+		}
+	} else if text := strings.TrimSpace(string(body)); text != "" {
+		internal.Message = text
+	}
+	if internal.RequestID == "" && resp.Request != nil {
+		internal.RequestID = resp.Request.Header.Get(RequestIDHeader)
+	}
+	if internal.Message == "" {
+		internal.Message = http.StatusText(resp.StatusCode)
+	}
+	internal.pathCode = defaultErrorCode(resp.StatusCode, requestPath(resp))
+	if internal.Code == "" {
+		internal.Code = internal.pathCode
+	}
+
+	apiErr := &APIError{internal: internal}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return apiErr
+	}
+
+	retryAfter := parsed.RetryAfter
+	if wait, ok := retry.RetryAfter(resp, time.Now()); ok {
+		retryAfter = int(math.Ceil(wait.Seconds()))
+	}
+	if retryAfter <= 0 {
+		retryAfter = 60
+	}
+	return &RateLimitError{internal: &InternalRateLimitError{RetryAfter: retryAfter}, api: apiErr}
+}
+
+// detailsString renders the details field, which may be a string or an object.
+func detailsString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+	return string(raw)
+}
+
+// requestPath returns the URL path of the request that produced resp.
+func requestPath(resp *http.Response) string {
+	if resp.Request == nil || resp.Request.URL == nil {
+		return ""
+	}
+	return resp.Request.URL.Path
+}
+
+// defaultErrorCode derives an error code for responses whose body carries
+// none. 404s on a known resource get that resource's code; everything else
+// gets the upper snake case form of the status text (e.g. NOT_FOUND).
+func defaultErrorCode(status int, path string) string {
+	if status == http.StatusNotFound {
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		code, at := "", -1
+		for i, seg := range segments {
+			if c, ok := resourceNotFoundCodes[seg]; ok && i+1 < len(segments) {
+				code, at = c, i
+			} else if code != "" && i > at+1 && i+1 < len(segments) {
+				// An id under a nested collection: the parent exists, the child does not
+				code = ""
+			}
+		}
+		if code != "" {
+			return code
+		}
+	}
+	text := http.StatusText(status)
+	if text == "" {
+		return fmt.Sprintf("HTTP_%d", status)
+	}
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
+
 // NewError creates a new general error with the given message
 func NewError(msg string, args ...interface{}) error {
 	return fmt.Errorf(msg, args...)
@@ -0,0 +1,164 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newErrorTestClient(t *testing.T, status int, header http.Header, body string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+}
+
+func TestClient_Do_ReturnsAPIError(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusBadRequest,
+		http.Header{"X-Request-Id": {"req-42"}},
+		`{"code":"INVALID_PARAM","message":"bad page","details":{"field":"page"}}`)
+
+	_, err := client.Organization.Get(context.Background(), "1")
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode() != http.StatusBadRequest || apiErr.Code() != "INVALID_PARAM" || apiErr.Message() != "bad page" {
+		t.Errorf("unexpected error fields: %d %q %q", apiErr.StatusCode(), apiErr.Code(), apiErr.Message())
+	}
+	if apiErr.Details() != `{"field":"page"}` {
+		t.Errorf("unexpected details: %q", apiErr.Details())
+	}
+	if apiErr.RequestID() != "req-42" {
+		t.Errorf("expected request ID req-42, got %q", apiErr.RequestID())
+	}
+	if len(apiErr.RawBody()) == 0 {
+		t.Error("expected raw body to be kept")
+	}
+}
+
+func TestClient_Do_NotFoundMatchesSentinels(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusNotFound, nil, `{"message":"not found"}`)
+
+	_, err := client.Organization.Get(context.Background(), "1")
+	if !IsNotFoundError(err) {
+		t.Errorf("expected IsNotFoundError, got %v", err)
+	}
+	if !errors.Is(err, ErrOrgNotFound) {
+		t.Errorf("expected errors.Is(err, ErrOrgNotFound), got %v", err)
+	}
+	if errors.Is(err, ErrAgentNotFound) {
+		t.Error("organization 404 should not match ErrAgentNotFound")
+	}
+
+	_, err = client.Agent.Get(context.Background(), "a1")
+	if !errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("expected errors.Is(err, ErrAgentNotFound), got %v", err)
+	}
+}
+
+func TestClient_Do_GenericNotFoundCodeMatchesResourceSentinel(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusNotFound, nil, `{"code":"NOT_FOUND","message":"no such record"}`)
+	ctx := context.Background()
+
+	_, err := client.Organization.Get(ctx, "1")
+	if !errors.Is(err, ErrOrgNotFound) || errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("organization 404 with a generic code: got %v, want only ErrOrgNotFound to match", err)
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code() != "NOT_FOUND" {
+		t.Errorf("Code() = %q, want the API's NOT_FOUND", apiErr.Code())
+	}
+	_, err = client.Agent.Get(ctx, "a1")
+	if !errors.Is(err, ErrAgentNotFound) || errors.Is(err, ErrOrgNotFound) {
+		t.Errorf("agent 404 with a generic code: got %v, want only ErrAgentNotFound to match", err)
+	}
+}
+
+func TestClient_Do_AuthError(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusUnauthorized, nil, "")
+	_, err := client.Account.Get(context.Background())
+	if !IsAuthError(err) {
+		t.Errorf("expected IsAuthError, got %v", err)
+	}
+	if IsRetryable(err) {
+		t.Error("401 should not be retryable")
+	}
+}
+
+func TestClient_Do_RateLimitError(t *testing.T) {
+	client := newErrorTestClient(t, http.StatusTooManyRequests, http.Header{"Retry-After": {"17"}}, `{"message":"slow down"}`)
+	_, err := client.Account.Get(context.Background())
+	rlErr, ok := AsRateLimitError(err)
+	if !ok {
+		t.Fatalf("expected *RateLimitError, got %T: %v", err, err)
+	}
+	if rlErr.RetryAfter() != 17 {
+		t.Errorf("expected RetryAfter 17, got %d", rlErr.RetryAfter())
+	}
+	if !IsAPIError(err) {
+		t.Error("rate limit error should unwrap to an APIError")
+	}
+	if !IsRetryable(err) || !IsTemporary(err) {
+		t.Error("rate limit error should be retryable and temporary")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+		temporary bool
+	}{
+		{"nil", nil, false, false},
+		{"500", &APIError{internal: &InternalAPIError{StatusCode: 500}}, true, false},
+		{"503", fmt.Errorf("wrapped: %w", &APIError{internal: &InternalAPIError{StatusCode: 503}}), true, true},
+		{"400", &APIError{internal: &InternalAPIError{StatusCode: 400}}, false, false},
+		{"transport", newRequestError(nil, errors.New("connection reset by peer")), true, false},
+		{"canceled", newRequestError(nil, context.Canceled), false, false},
+		{"plain", errors.New("boom"), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.retryable)
+			}
+			if got := IsTemporary(tt.err); got != tt.temporary {
+				t.Errorf("IsTemporary() = %v, want %v", got, tt.temporary)
+			}
+		})
+	}
+}
+
+func TestDefaultErrorCode(t *testing.T) {
+	tests := map[string]struct {
+		status int
+		path   string
+		want   string
+	}{
+		"agent":          {404, "/v1/agents/a1", "AGENT_NOT_FOUND"},
+		"agent stats":    {404, "/v1/agents/a1/stats", "AGENT_NOT_FOUND"},
+		"org user":       {404, "/v1/organizations/1/users/2", "NOT_FOUND"},
+		"unknown":        {404, "/v1/reports/r1", "NOT_FOUND"},
+		"server":         {503, "/v1/agents", "SERVICE_UNAVAILABLE"},
+		"unprocessable":  {422, "/v1/organizations", "UNPROCESSABLE_ENTITY"},
+		"unknown status": {599, "/", "HTTP_599"},
+	}
+	for name, tt := range tests {
+		if got := defaultErrorCode(tt.status, tt.path); got != tt.want {
+			t.Errorf("%s: defaultErrorCode() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
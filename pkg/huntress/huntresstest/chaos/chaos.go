@@ -0,0 +1,391 @@
+// Package chaos provides an http.RoundTripper that injects failures into
+// traffic to the Huntress API, for testing that retries, circuit breaking
+// and pagination survive a misbehaving server.
+//
+// Rules pick the requests to disturb by method and path, and fire either on
+// a script (skip the first After matches, then fire Times times) or at
+// random with a Probability drawn from a seeded source, so failing runs can
+// be reproduced:
+//
+//	tr := chaos.New(chaos.WithSeed(42), chaos.WithRules(
+//		chaos.Rule{Path: "/agents", Fault: chaos.ServerError(503), Times: 3},
+//		chaos.Rule{Fault: chaos.Latency(20*time.Millisecond, 10*time.Millisecond), Probability: 0.3},
+//		chaos.Rule{Path: "/incidents", Fault: chaos.ConnectionReset(), Probability: 0.1},
+//	))
+//	client := huntress.New(
+//		huntress.WithCredentials(key, secret),
+//		huntress.WithHTTPClient(tr.Client()),
+//	)
+//
+// It pairs with huntresstest.NewServer, which provides the healthy API the
+// faults are injected into.
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// kind identifies what a Fault does.
+type kind int
+
+const (
+	kindLatency kind = iota
+	kindReset
+	kindRateLimit
+	kindServerError
+	kindTruncate
+	kindMalformed
+)
+
+// Fault is a failure to inject. Create one with Latency, ConnectionReset,
+// RateLimited, ServerError, TruncatedBody or MalformedJSON.
+type Fault struct {
+	kind       kind
+	delay      time.Duration
+	jitter     time.Duration
+	status     int
+	retryAfter time.Duration
+	keep       int
+}
+
+// Latency delays the request by d plus a random duration up to jitter, then
+// lets it through. Unlike other faults, latency adds to whatever fault
+// fires next. The wait ends early, with the context's error, if the request
+// is canceled.
+func Latency(d, jitter time.Duration) Fault {
+	return Fault{kind: kindLatency, delay: d, jitter: jitter}
+}
+
+// ConnectionReset fails the request with ECONNRESET, as if the server had
+// dropped the connection, without sending it.
+func ConnectionReset() Fault {
+	return Fault{kind: kindReset}
+}
+
+// RateLimited answers with a 429 carrying a Retry-After header of
+// retryAfter, rounded up to whole seconds, without sending the request.
+func RateLimited(retryAfter time.Duration) Fault {
+	return Fault{kind: kindRateLimit, status: http.StatusTooManyRequests, retryAfter: retryAfter}
+}
+
+// ServerError answers with status, which should be a 5xx code, without
+// sending the request. Combine it with Rule.Times for a burst.
+func ServerError(status int) Fault {
+	return Fault{kind: kindServerError, status: status}
+}
+
+// TruncatedBody sends the request but cuts the response body off after keep
+// bytes, so reading it fails with io.ErrUnexpectedEOF. The Content-Length
+// header still announces the full body.
+func TruncatedBody(keep int) Fault {
+	return Fault{kind: kindTruncate, keep: keep}
+}
+
+// MalformedJSON sends the request but corrupts the response body so it no
+// longer parses as JSON, as when a proxy mangles or cuts off a response.
+func MalformedJSON() Fault {
+	return Fault{kind: kindMalformed}
+}
+
+// String describes the fault.
+func (f Fault) String() string {
+	switch f.kind {
+	case kindLatency:
+		if f.jitter > 0 {
+			return fmt.Sprintf("latency %s±%s", f.delay, f.jitter)
+		}
+		return "latency " + f.delay.String()
+	case kindReset:
+		return "connection reset"
+	case kindRateLimit:
+		return "rate limited, retry after " + f.retryAfter.String()
+	case kindServerError:
+		return "server error " + strconv.Itoa(f.status)
+	case kindTruncate:
+		return fmt.Sprintf("body truncated to %d bytes", f.keep)
+	case kindMalformed:
+		return "malformed JSON"
+	}
+	return "unknown fault"
+}
+
+// Rule injects a Fault into matching requests.
+type Rule struct {
+	// Method restricts the rule to one HTTP method. Empty matches all.
+	Method string
+	// Path restricts the rule to URLs whose path contains it at segment
+	// boundaries, so "/agents" matches /v1/agents and /v1/agents/42 but not
+	// /v1/agents-archive. Empty matches all.
+	Path string
+	// Fault is what happens to requests the rule fires on.
+	Fault Fault
+	// Probability is the chance, between 0 and 1, that the rule fires on an
+	// eligible request. Zero or one fires on every one.
+	Probability float64
+	// After is the number of matching requests let through before the rule
+	// becomes eligible.
+	After int
+	// Times caps how often the rule fires. Zero means no limit.
+	Times int
+}
+
+// Event records a fault the Transport injected.
+type Event struct {
+	Method string
+	Path   string
+	Fault  Fault
+}
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithBase sets the transport requests are sent through. The default is
+// http.DefaultTransport.
+func WithBase(rt http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.base = rt
+	}
+}
+
+// WithSeed seeds the random source behind Rule.Probability and latency
+// jitter. The default seed is 0, so runs are reproducible unless the seed is
+// varied deliberately. Concurrent requests draw from the source in the
+// order they arrive, so only sequential runs repeat exactly.
+func WithSeed(seed int64) Option {
+	return func(t *Transport) {
+		t.rng = rand.New(rand.NewSource(seed)) // #nosec G404 -- reproducible test faults, not security sensitive
+	}
+}
+
+// WithRules adds rules. See Transport.Add.
+func WithRules(rules ...Rule) Option {
+	return func(t *Transport) {
+		t.add(rules)
+	}
+}
+
+// Transport is an http.RoundTripper that injects faults according to its
+// rules. It is safe for concurrent use.
+type Transport struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	rules  []*ruleState
+	events []Event
+}
+
+// ruleState is a rule and how often it has matched and fired.
+type ruleState struct {
+	Rule
+	matched int
+	fired   int
+}
+
+// New creates a Transport.
+func New(opts ...Option) *Transport {
+	t := &Transport{base: http.DefaultTransport}
+	WithSeed(0)(t)
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Client returns an http.Client using the transport, for use with
+// huntress.WithHTTPClient.
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Add adds rules. Rules are evaluated in the order they were added: latency
+// from every rule that fires is summed, and the first other fault that fires
+// decides the outcome.
+func (t *Transport) Add(rules ...Rule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(rules)
+}
+
+func (t *Transport) add(rules []Rule) {
+	for _, r := range rules {
+		t.rules = append(t.rules, &ruleState{Rule: r})
+	}
+}
+
+// Clear removes every rule, so later requests pass through untouched.
+func (t *Transport) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rules = nil
+}
+
+// Events returns the faults injected so far, oldest first.
+func (t *Transport) Events() []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Event(nil), t.events...)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay, fault := t.pick(req)
+	if delay > 0 {
+		if err := sleep(req.Context(), delay); err != nil {
+			closeBody(req)
+			return nil, err
+		}
+	}
+	if fault == nil {
+		return t.base.RoundTrip(req)
+	}
+	switch fault.kind {
+	case kindReset:
+		closeBody(req)
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	case kindRateLimit, kindServerError:
+		closeBody(req)
+		return errorResponse(req, *fault), nil
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if fault.kind == kindTruncate {
+		resp.Body = &truncatedBody{r: bytes.NewReader(body[:min(fault.keep, len(body))])}
+		return resp, nil
+	}
+	// A '<' outside a string is never valid JSON, and inside one it leaves
+	// the string unterminated
+	body = append(body[:len(body)/2:len(body)/2], '<')
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
+
+// pick evaluates the rules for req, returning the total latency to inject
+// and the fault that decides the outcome, if any.
+func (t *Transport) pick(req *http.Request) (time.Duration, *Fault) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var delay time.Duration
+	var fault *Fault
+	for _, r := range t.rules {
+		if (r.Method != "" && r.Method != req.Method) || !matchPath(req.URL.Path, r.Path) {
+			continue
+		}
+		// Every matching rule counts the request toward its After, even
+		// once an earlier rule has decided the outcome
+		r.matched++
+		if fault != nil || r.matched <= r.After || (r.Times > 0 && r.fired >= r.Times) {
+			continue
+		}
+		if r.Probability > 0 && r.Probability < 1 && t.rng.Float64() >= r.Probability {
+			continue
+		}
+		r.fired++
+		t.events = append(t.events, Event{Method: req.Method, Path: req.URL.Path, Fault: r.Fault})
+		if r.Fault.kind == kindLatency {
+			delay += r.Fault.delay
+			if r.Fault.jitter > 0 {
+				delay += time.Duration(t.rng.Int63n(int64(r.Fault.jitter) + 1))
+			}
+			continue
+		}
+		f := r.Fault
+		fault = &f
+	}
+	return delay, fault
+}
+
+// matchPath reports whether path contains pattern at segment boundaries.
+func matchPath(path, pattern string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return true
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "/" + pattern
+	}
+	return strings.Contains(path+"/", pattern+"/")
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeBody closes the request body, as a RoundTripper must even when it
+// does not send the request.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// errorResponse builds the response for a 429 or 5xx fault, in the API's
+// error format.
+func errorResponse(req *http.Request, f Fault) *http.Response {
+	code := "SERVER_ERROR"
+	header := http.Header{"Content-Type": {"application/json"}}
+	if f.kind == kindRateLimit {
+		code = "RATE_LIMITED"
+		header.Set("Retry-After", strconv.Itoa(int(math.Ceil(f.retryAfter.Seconds()))))
+	}
+	body := fmt.Sprintf(`{"code":%q,"message":"injected fault: %s"}`, code, f)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.status, http.StatusText(f.status)),
+		StatusCode:    f.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// truncatedBody reads a cut-off body, ending in io.ErrUnexpectedEOF the way
+// a connection closed mid-response does.
+type truncatedBody struct {
+	r *bytes.Reader
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *truncatedBody) Close() error {
+	return nil
+}
@@ -0,0 +1,172 @@
+package chaos_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress"
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress/huntresstest"
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress/huntresstest/chaos"
+)
+
+// setup starts a fake API with five organizations served two per page, and
+// returns a client that reaches it through tr.
+func setup(t *testing.T, tr *chaos.Transport, opts ...huntress.Option) *huntress.Client {
+	t.Helper()
+	srv := huntresstest.NewServer(huntresstest.WithPerPage(2), huntresstest.WithGenerated(huntresstest.GenerateConfig{
+		Seed: 1, Organizations: 5, Agents: 5,
+	}))
+	t.Cleanup(srv.Close)
+	return srv.Client(append([]huntress.Option{huntress.WithHTTPClient(tr.Client())}, opts...)...)
+}
+
+func withRetries() huntress.Option {
+	return huntress.WithRetryConfig(3, time.Millisecond, 5*time.Millisecond)
+}
+
+func TestServerErrorBurst_Retried(t *testing.T) {
+	tr := chaos.New(chaos.WithRules(chaos.Rule{Path: "/organizations", Fault: chaos.ServerError(http.StatusServiceUnavailable), Times: 2}))
+	client := setup(t, tr, withRetries())
+
+	if _, err := client.Organization.Get(context.Background(), "1"); err != nil {
+		t.Fatalf("Get through a burst of two 503s: %v", err)
+	}
+	if n := len(tr.Events()); n != 2 {
+		t.Errorf("injected %d faults, want 2", n)
+	}
+}
+
+func TestConnectionReset(t *testing.T) {
+	tr := chaos.New(chaos.WithRules(chaos.Rule{Fault: chaos.ConnectionReset(), Times: 1}))
+	client := setup(t, tr)
+
+	_, err := client.Account.Get(context.Background())
+	if !errors.Is(err, syscall.ECONNRESET) || !huntress.IsRetryable(err) {
+		t.Fatalf("error = %v, want a retryable ECONNRESET", err)
+	}
+
+	tr.Add(chaos.Rule{Fault: chaos.ConnectionReset(), Times: 1})
+	client = setup(t, tr, withRetries())
+	if _, err := client.Account.Get(context.Background()); err != nil {
+		t.Errorf("Get with retries after a reset: %v", err)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	tr := chaos.New(chaos.WithRules(chaos.Rule{Method: http.MethodGet, Fault: chaos.RateLimited(1500 * time.Millisecond)}))
+	client := setup(t, tr)
+
+	_, err := client.Account.Get(context.Background())
+	var rl *huntress.RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter() != 2 {
+		t.Fatalf("error = %v, want a RateLimitError with RetryAfter 2", err)
+	}
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	tr := chaos.New(chaos.WithRules(chaos.Rule{Path: "/agents", Fault: chaos.ServerError(http.StatusBadGateway)}))
+	client := setup(t, tr, huntress.WithCircuitBreaker(huntress.CircuitBreakerConfig{MinRequests: 3, OpenTimeout: time.Minute}))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Agent.Get(ctx, "1"); err == nil || huntress.IsCircuitOpenError(err) {
+			t.Fatalf("call %d: error = %v, want a 502", i, err)
+		}
+	}
+	if _, err := client.Agent.Get(ctx, "1"); !huntress.IsCircuitOpenError(err) {
+		t.Errorf("error = %v, want the circuit to be open", err)
+	}
+	if n := len(tr.Events()); n != 3 {
+		t.Errorf("%d requests reached the transport, want 3", n)
+	}
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Errorf("other endpoint groups should be unaffected: %v", err)
+	}
+}
+
+func TestPaginationSurvivesFaults(t *testing.T) {
+	tr := chaos.New(chaos.WithRules(
+		chaos.Rule{Path: "/organizations", Fault: chaos.ServerError(http.StatusServiceUnavailable), After: 1, Times: 1},
+		chaos.Rule{Path: "/organizations", Fault: chaos.ConnectionReset(), After: 3, Times: 1},
+	))
+	client := setup(t, tr, withRetries())
+
+	var ids []string
+	for org, err := range client.Organization.All(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		ids = append(ids, org.ID)
+	}
+	if want := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("All yielded %v, want %v", ids, want)
+	}
+	if n := len(tr.Events()); n != 2 {
+		t.Errorf("injected %d faults, want 2", n)
+	}
+}
+
+func TestCorruptBodies(t *testing.T) {
+	tr := chaos.New(chaos.WithRules(chaos.Rule{Path: "/organizations", Fault: chaos.TruncatedBody(10), After: 1, Times: 1}))
+	client := setup(t, tr)
+
+	var err error
+	var n int
+	for _, err = range client.Organization.All(context.Background(), nil) {
+		if err != nil {
+			break
+		}
+		n++
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) || n != 2 {
+		t.Errorf("after %d organizations error = %v, want io.ErrUnexpectedEOF on the second page", n, err)
+	}
+
+	tr.Add(chaos.Rule{Fault: chaos.MalformedJSON(), Times: 1})
+	_, err = client.Account.Get(context.Background())
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("error = %v, want a JSON syntax error", err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	tr := chaos.New(chaos.WithRules(chaos.Rule{Fault: chaos.Latency(time.Hour, 0)}))
+	client := setup(t, tr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Account.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSeedReproducible(t *testing.T) {
+	run := func(seed int64) []string {
+		tr := chaos.New(chaos.WithSeed(seed), chaos.WithRules(chaos.Rule{Fault: chaos.ServerError(http.StatusInternalServerError), Probability: 0.5}))
+		client := setup(t, tr)
+		var outcomes []string
+		for i := 0; i < 20; i++ {
+			if _, err := client.Account.Get(context.Background()); err != nil {
+				outcomes = append(outcomes, "fail")
+			} else {
+				outcomes = append(outcomes, "ok")
+			}
+		}
+		return outcomes
+	}
+	a, b := run(7), run(7)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed gave different outcomes:\n%v\n%v", a, b)
+	}
+	if reflect.DeepEqual(a, run(8)) {
+		t.Error("different seeds gave identical outcomes")
+	}
+}
@@ -0,0 +1,244 @@
+package huntresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress"
+)
+
+// Fixtures is the data a Server holds. It can be built in code, loaded from
+// a JSON file with LoadFixtures, or produced by Generate.
+type Fixtures struct {
+	Account       *huntress.Account          `json:"account,omitempty"`
+	Billing       *huntress.BillingSummary   `json:"billing,omitempty"`
+	Organizations []*huntress.Organization   `json:"organizations,omitempty"`
+	Agents        []*huntress.Agent          `json:"agents,omitempty"`
+	Incidents     []*huntress.Incident       `json:"incidents,omitempty"`
+	Reports       []*huntress.Report         `json:"reports,omitempty"`
+	Schedules     []*huntress.ReportSchedule `json:"schedules,omitempty"`
+	Invoices      []*huntress.Invoice        `json:"invoices,omitempty"`
+	Webhooks      []*huntress.Webhook        `json:"webhooks,omitempty"`
+	AuditLogs     []*huntress.AuditLog       `json:"audit_logs,omitempty"`
+}
+
+// LoadFixtures reads Fixtures from a JSON file.
+func LoadFixtures(path string) (Fixtures, error) {
+	var f Fixtures
+	data, err := os.ReadFile(path) // #nosec G304 -- fixture path is chosen by the test
+	if err != nil {
+		return f, fmt.Errorf("huntresstest: reading fixtures: %w", err)
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("huntresstest: decoding fixtures %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// seed adds f to the server's data. s.mu must be held, or s not yet
+// serving.
+func (s *Server) seed(f Fixtures) {
+	f = cloneFixtures(f)
+	if f.Account != nil {
+		s.data.Account = f.Account
+	}
+	if s.data.Account == nil {
+		s.data.Account = &huntress.Account{ID: "1", Name: "Test Account", CreatedAt: baseTime, UpdatedAt: baseTime}
+	}
+	if f.Billing != nil {
+		s.data.Billing = f.Billing
+	}
+	if s.data.Billing == nil {
+		s.data.Billing = &huntress.BillingSummary{AccountID: s.data.Account.ID, Currency: "USD", BillingPeriod: "monthly"}
+	}
+	for _, o := range f.Organizations {
+		o.ID = s.seedID("organizations", o.ID)
+		s.data.Organizations = append(s.data.Organizations, o)
+	}
+	for _, a := range f.Agents {
+		a.ID = s.seedID("agents", a.ID)
+		s.data.Agents = append(s.data.Agents, a)
+	}
+	for _, i := range f.Incidents {
+		i.ID = s.seedID("incidents", i.ID)
+		s.data.Incidents = append(s.data.Incidents, i)
+	}
+	for _, r := range f.Reports {
+		r.ID = s.seedID("reports", r.ID)
+		s.data.Reports = append(s.data.Reports, r)
+	}
+	for _, r := range f.Schedules {
+		r.ID = s.seedID("schedules", r.ID)
+		s.data.Schedules = append(s.data.Schedules, r)
+	}
+	for _, i := range f.Invoices {
+		i.ID = s.seedID("invoices", i.ID)
+		s.data.Invoices = append(s.data.Invoices, i)
+	}
+	for _, h := range f.Webhooks {
+		if h.ID == 0 {
+			h.ID = s.newID("webhooks")
+		} else {
+			s.reserveID("webhooks", strconv.FormatInt(h.ID, 10))
+		}
+		s.data.Webhooks = append(s.data.Webhooks, h)
+	}
+	for _, l := range f.AuditLogs {
+		l.ID = s.seedID("audit-logs", l.ID)
+		s.data.AuditLogs = append(s.data.AuditLogs, l)
+	}
+}
+
+// seedID keeps a seeded item's ID, or assigns one if it has none.
+func (s *Server) seedID(kind, id string) string {
+	if id == "" {
+		return strconv.FormatInt(s.newID(kind), 10)
+	}
+	s.reserveID(kind, id)
+	return id
+}
+
+// cloneFixtures deep-copies f so callers cannot reach the server's data.
+func cloneFixtures(f Fixtures) Fixtures {
+	data, err := json.Marshal(f)
+	if err != nil {
+		panic(fmt.Sprintf("huntresstest: copying fixtures: %v", err))
+	}
+	var out Fixtures
+	if err := json.Unmarshal(data, &out); err != nil {
+		panic(fmt.Sprintf("huntresstest: copying fixtures: %v", err))
+	}
+	return out
+}
+
+// baseTime anchors generated timestamps so generated data is reproducible.
+var baseTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// GenerateConfig sizes the data produced by Generate.
+type GenerateConfig struct {
+	// Seed makes the output reproducible: the same config always produces
+	// the same data
+	Seed int64
+	// Organizations, Agents and Incidents are the total number of each.
+	// Agents and incidents are spread evenly across organizations, and
+	// each incident belongs to an agent of its organization when it has one.
+	Organizations int
+	Agents        int
+	Incidents     int
+	// Invoices is the number of monthly invoices, oldest first
+	Invoices int
+}
+
+var (
+	orgWords      = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark", "Wayne", "Tyrell", "Cyberdyne", "Soylent"}
+	orgSuffixes   = []string{"Corp", "Industries", "Labs", "Holdings", "Systems"}
+	industries    = []string{"healthcare", "finance", "manufacturing", "education", "retail"}
+	platforms     = []huntress.AgentPlatform{huntress.AgentPlatformWindows, huntress.AgentPlatformWindows, huntress.AgentPlatformMac, huntress.AgentPlatformLinux}
+	agentStatuses = []huntress.AgentStatus{huntress.AgentStatusOnline, huntress.AgentStatusOnline, huntress.AgentStatusOnline, huntress.AgentStatusOffline}
+	severities    = []huntress.IncidentSeverity{huntress.IncidentSeverityLow, huntress.IncidentSeverityMedium, huntress.IncidentSeverityHigh, huntress.IncidentSeverityCritical}
+	incidentKinds = []string{"malware", "persistence", "ransomware_canary", "suspicious_login", "process_anomaly"}
+)
+
+// Generate builds synthetic data sized by cfg. It is deterministic: the
+// same config always yields the same data, IDs and timestamps included.
+func Generate(cfg GenerateConfig) Fixtures {
+	rng := rand.New(rand.NewSource(cfg.Seed)) // #nosec G404 -- test data, not security sensitive
+	var f Fixtures
+
+	for i := 1; i <= cfg.Organizations; i++ {
+		created := baseTime.Add(time.Duration(i) * time.Hour)
+		name := fmt.Sprintf("%s %s %d", orgWords[rng.Intn(len(orgWords))], orgSuffixes[rng.Intn(len(orgSuffixes))], i)
+		f.Organizations = append(f.Organizations, &huntress.Organization{
+			ID:        strconv.Itoa(i),
+			Name:      name,
+			Status:    string(huntress.OrganizationStatusActive),
+			Industry:  industries[rng.Intn(len(industries))],
+			CreatedAt: created,
+			UpdatedAt: created,
+			ContactInfo: huntress.Contact{
+				Name:  fmt.Sprintf("Admin %d", i),
+				Email: fmt.Sprintf("admin@org%d.example.com", i),
+			},
+		})
+	}
+
+	orgAgents := make(map[string][]string)
+	for i := 1; i <= cfg.Agents; i++ {
+		orgID := ""
+		if cfg.Organizations > 0 {
+			orgID = strconv.Itoa((i-1)%cfg.Organizations + 1)
+		}
+		id := strconv.Itoa(i)
+		orgAgents[orgID] = append(orgAgents[orgID], id)
+		platform := platforms[rng.Intn(len(platforms))]
+		created := baseTime.Add(time.Duration(i) * time.Minute)
+		f.Agents = append(f.Agents, &huntress.Agent{
+			ID:             id,
+			Version:        fmt.Sprintf("0.13.%d", rng.Intn(20)),
+			Hostname:       fmt.Sprintf("host-%04d", i),
+			IPV4Address:    fmt.Sprintf("10.%d.%d.%d", rng.Intn(256), rng.Intn(256), 1+rng.Intn(254)),
+			Platform:       string(platform),
+			OS:             string(platform),
+			Status:         string(agentStatuses[rng.Intn(len(agentStatuses))]),
+			OrganizationID: orgID,
+			LastSeenAt:     created.Add(time.Duration(rng.Intn(30*24)) * time.Hour),
+			CreatedAt:      created,
+			UpdatedAt:      created,
+			Settings:       huntress.AgentSettings{AutoUpdate: true, MonitorProcesses: true, MonitorServices: rng.Intn(2) == 0},
+		})
+	}
+
+	for i := 1; i <= cfg.Incidents; i++ {
+		orgID, agentID := "", ""
+		if cfg.Organizations > 0 {
+			orgID = strconv.Itoa((i-1)%cfg.Organizations + 1)
+		}
+		if agents := orgAgents[orgID]; len(agents) > 0 {
+			agentID = agents[rng.Intn(len(agents))]
+		}
+		kind := incidentKinds[rng.Intn(len(incidentKinds))]
+		detected := baseTime.Add(time.Duration(i) * 6 * time.Hour)
+		inc := &huntress.Incident{
+			ID:             strconv.Itoa(i),
+			Type:           kind,
+			Title:          fmt.Sprintf("%s detected", kind),
+			Severity:       string(severities[rng.Intn(len(severities))]),
+			Status:         string(huntress.IncidentStatusNew),
+			OrganizationID: orgID,
+			AgentID:        agentID,
+			DetectedAt:     detected,
+			UpdatedAt:      detected,
+		}
+		if rng.Intn(3) == 0 {
+			inc.Status = string(huntress.IncidentStatusResolved)
+			inc.ResolvedAt = detected.Add(time.Duration(1+rng.Intn(48)) * time.Hour)
+			inc.UpdatedAt = inc.ResolvedAt
+		}
+		f.Incidents = append(f.Incidents, inc)
+	}
+
+	for i := 1; i <= cfg.Invoices; i++ {
+		issued := baseTime.AddDate(0, i-1, 0)
+		amount := float64(cfg.Agents) * 3.5
+		f.Invoices = append(f.Invoices, &huntress.Invoice{
+			ID:            strconv.Itoa(i),
+			InvoiceNumber: fmt.Sprintf("INV-%05d", i),
+			AccountID:     "1",
+			Amount:        amount,
+			Currency:      "USD",
+			Status:        "paid",
+			IssuedAt:      issued,
+			DueAt:         issued.AddDate(0, 0, 30),
+			PaidAt:        issued.AddDate(0, 0, 7),
+			BillingPeriod: issued.Format("2006-01"),
+			LineItems: []huntress.InvoiceLineItem{
+				{Description: "Managed EDR agents", Quantity: cfg.Agents, UnitPrice: 3.5, Amount: amount},
+			},
+		})
+	}
+	return f
+}
@@ -0,0 +1,609 @@
+package huntresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress"
+)
+
+// route dispatches a request to the handler for its resource. Handlers run
+// with s.mu held.
+func (s *Server) route(w http.ResponseWriter, r *http.Request, seg []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch seg[0] {
+	case "account":
+		s.handleAccount(w, r, seg[1:])
+	case "organizations":
+		s.handleOrganizations(w, r, seg[1:])
+	case "agents":
+		s.handleAgents(w, r, seg[1:])
+	case "incidents":
+		s.handleIncidents(w, r, seg[1:])
+	case "reports":
+		s.handleReports(w, r, seg[1:])
+	case "billing":
+		s.handleBilling(w, r, seg[1:])
+	case "webhooks":
+		s.handleWebhooks(w, r, seg[1:])
+	case "audit-logs":
+		s.handleAuditLogs(w, r, seg[1:])
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+r.URL.Path)
+	}
+}
+
+// ----- Account -----
+
+func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request, seg []string) {
+	switch {
+	case len(seg) == 0 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.data.Account)
+	case len(seg) == 0 && r.Method == http.MethodPatch:
+		id := s.data.Account.ID
+		if applyUpdate(w, r, s.data.Account) {
+			s.data.Account.ID, s.data.Account.UpdatedAt = id, now()
+			s.audit(r, "update", "account", id)
+			writeJSON(w, http.StatusOK, s.data.Account)
+		}
+	case len(seg) == 1 && seg[0] == "stats" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, huntress.AccountStats{
+			OrganizationCount: len(s.data.Organizations),
+			AgentCount:        len(s.data.Agents),
+			IncidentCount:     len(s.data.Incidents),
+		})
+	case len(seg) <= 1:
+		methodNotAllowed(w)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+r.URL.Path)
+	}
+}
+
+// ----- Organizations -----
+
+func (s *Server) handleOrganizations(w http.ResponseWriter, r *http.Request, seg []string) {
+	if len(seg) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			search := strings.ToLower(q.Get("search"))
+			listJSON(s, w, r, filter(s.data.Organizations, func(o *huntress.Organization) bool {
+				return matchParam(q, "status", o.Status) && hasTags(q, o.Tags) &&
+					(search == "" || strings.Contains(strings.ToLower(o.Name), search))
+			}))
+		case http.MethodPost:
+			var p huntress.OrganizationCreateParams
+			if !decodeBody(w, r, &p) {
+				return
+			}
+			if strings.TrimSpace(p.Name) == "" {
+				writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "name is required")
+				return
+			}
+			t := now()
+			org := &huntress.Organization{
+				ID:          strconv.FormatInt(s.newID("organizations"), 10),
+				Name:        p.Name,
+				Description: p.Description,
+				Status:      string(p.Status),
+				Tags:        p.Tags,
+				Industry:    p.Industry,
+				CreatedAt:   t,
+				UpdatedAt:   t,
+			}
+			if org.Status == "" {
+				org.Status = string(huntress.OrganizationStatusActive)
+			}
+			if p.Address != nil {
+				org.Address = huntress.Address{Street1: p.Address.Street1, Street2: p.Address.Street2, City: p.Address.City, State: p.Address.State, ZipCode: p.Address.ZipCode, Country: p.Address.Country}
+			}
+			if p.ContactInfo != nil {
+				_ = convert(p.ContactInfo, &org.ContactInfo)
+			}
+			if p.Settings != nil {
+				_ = convert(p.Settings, &org.Settings)
+			}
+			s.data.Organizations = append(s.data.Organizations, org)
+			s.audit(r, "create", "organization", org.ID)
+			writeJSON(w, http.StatusCreated, org)
+		default:
+			methodNotAllowed(w)
+		}
+		return
+	}
+	if len(seg) > 1 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+r.URL.Path)
+		return
+	}
+	i := index(s.data.Organizations, seg[0], func(o *huntress.Organization) string { return o.ID })
+	if i < 0 {
+		notFound(w, "organization", seg[0])
+		return
+	}
+	org := s.data.Organizations[i]
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, org)
+	case http.MethodPatch, http.MethodPut:
+		if applyUpdate(w, r, org) {
+			org.ID, org.UpdatedAt = seg[0], now()
+			s.audit(r, "update", "organization", org.ID)
+			writeJSON(w, http.StatusOK, org)
+		}
+	case http.MethodDelete:
+		s.data.Organizations = slices.Delete(s.data.Organizations, i, i+1)
+		s.audit(r, "delete", "organization", seg[0])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// ----- Agents -----
+
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request, seg []string) {
+	if len(seg) == 0 {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		q := r.URL.Query()
+		search := strings.ToLower(q.Get("search"))
+		listJSON(s, w, r, filter(s.data.Agents, func(a *huntress.Agent) bool {
+			return matchParam(q, "organization_id", a.OrganizationID) && matchParam(q, "status", a.Status) &&
+				matchParam(q, "platform", a.Platform) && matchParam(q, "hostname", a.Hostname) &&
+				matchParam(q, "version", a.Version) && matchParam(q, "ip_address", a.IPV4Address) && hasTags(q, a.Tags) &&
+				(search == "" || strings.Contains(strings.ToLower(a.Hostname), search))
+		}))
+		return
+	}
+	i := index(s.data.Agents, seg[0], func(a *huntress.Agent) string { return a.ID })
+	if i < 0 {
+		notFound(w, "agent", seg[0])
+		return
+	}
+	agent := s.data.Agents[i]
+	switch {
+	case len(seg) == 2 && seg[1] == "stats" && r.Method == http.MethodGet:
+		stats := huntress.AgentStatistics{DetectionsByType: map[string]int{}, LastUpdated: now()}
+		for _, inc := range s.data.Incidents {
+			if inc.AgentID != agent.ID {
+				continue
+			}
+			stats.TotalDetections++
+			stats.DetectionsByType[inc.Type]++
+			if inc.DetectedAt.After(stats.LastDetection) {
+				stats.LastDetection = inc.DetectedAt
+			}
+		}
+		if agent.Status == string(huntress.AgentStatusOnline) {
+			stats.UpTime = 99.9
+		}
+		writeJSON(w, http.StatusOK, stats)
+	case len(seg) > 1:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+r.URL.Path)
+	case r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, agent)
+	case r.Method == http.MethodPatch || r.Method == http.MethodPut:
+		if applyUpdate(w, r, agent) {
+			agent.ID, agent.UpdatedAt = seg[0], now()
+			s.audit(r, "update", "agent", agent.ID)
+			writeJSON(w, http.StatusOK, agent)
+		}
+	case r.Method == http.MethodDelete:
+		s.data.Agents = slices.Delete(s.data.Agents, i, i+1)
+		s.audit(r, "delete", "agent", seg[0])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// ----- Incidents -----
+
+func (s *Server) handleIncidents(w http.ResponseWriter, r *http.Request, seg []string) {
+	if len(seg) == 0 {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		q := r.URL.Query()
+		listJSON(s, w, r, filter(s.data.Incidents, func(inc *huntress.Incident) bool {
+			return matchParam(q, "organization_id", inc.OrganizationID) && matchParam(q, "agent_id", inc.AgentID) &&
+				matchParam(q, "status", inc.Status) && matchParam(q, "severity", inc.Severity) &&
+				matchParam(q, "type", inc.Type) && matchParam(q, "assigned_to", inc.AssignedTo) && hasTags(q, inc.Tags)
+		}))
+		return
+	}
+	i := index(s.data.Incidents, seg[0], func(inc *huntress.Incident) string { return inc.ID })
+	if i < 0 {
+		notFound(w, "incident", seg[0])
+		return
+	}
+	inc := s.data.Incidents[i]
+	switch {
+	case len(seg) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, inc)
+	case len(seg) == 2 && seg[1] == "status" && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		var body struct {
+			Status string `json:"status"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		switch huntress.IncidentStatus(body.Status) {
+		case huntress.IncidentStatusNew, huntress.IncidentStatusInProgress, huntress.IncidentStatusResolved, huntress.IncidentStatusClosed:
+		default:
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", fmt.Sprintf("invalid status %q", body.Status))
+			return
+		}
+		inc.Status, inc.UpdatedAt = body.Status, now()
+		if body.Status == string(huntress.IncidentStatusResolved) {
+			inc.ResolvedAt = inc.UpdatedAt
+		}
+		s.audit(r, "update_status", "incident", inc.ID)
+		writeJSON(w, http.StatusOK, inc)
+	case len(seg) == 2 && seg[1] == "assign" && r.Method == http.MethodPost:
+		var body struct {
+			UserID string `json:"user_id"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		if body.UserID == "" {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "user_id is required")
+			return
+		}
+		inc.AssignedTo, inc.UpdatedAt = body.UserID, now()
+		s.audit(r, "assign", "incident", inc.ID)
+		writeJSON(w, http.StatusOK, inc)
+	case len(seg) <= 2 && (len(seg) == 1 || seg[1] == "status" || seg[1] == "assign"):
+		methodNotAllowed(w)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+r.URL.Path)
+	}
+}
+
+// ----- Reports -----
+
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request, seg []string) {
+	q := r.URL.Query()
+	switch {
+	case len(seg) == 0 && r.Method == http.MethodGet:
+		listJSON(s, w, r, filter(s.data.Reports, func(rep *huntress.Report) bool {
+			return matchParam(q, "type", rep.Type) && matchParam(q, "format", rep.Format) &&
+				matchParam(q, "status", rep.Status) && matchParam(q, "organization_id", rep.OrganizationID)
+		}))
+	case len(seg) == 0 && r.Method == http.MethodPost:
+		var in huntress.ReportGenerateInput
+		if !decodeBody(w, r, &in) {
+			return
+		}
+		if in.Type == "" {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "type is required")
+			return
+		}
+		if in.Format == "" {
+			in.Format = "pdf"
+		}
+		t := now()
+		rep := &huntress.Report{
+			ID:             strconv.FormatInt(s.newID("reports"), 10),
+			Type:           in.Type,
+			Status:         "completed",
+			Format:         in.Format,
+			OrganizationID: in.OrganizationID,
+			CreatedAt:      t,
+			CompletedAt:    t,
+		}
+		rep.URL = s.URL() + "/reports/" + rep.ID + "/download"
+		s.data.Reports = append(s.data.Reports, rep)
+		s.audit(r, "create", "report", rep.ID)
+		writeJSON(w, http.StatusCreated, rep)
+	case len(seg) == 0:
+		methodNotAllowed(w)
+	case len(seg) == 1 && seg[0] == "summary" && r.Method == http.MethodGet:
+		orgID := q.Get("organization_id")
+		incidents := s.incidentsFor(orgID)
+		open := len(filter(incidents, func(inc *huntress.Incident) bool {
+			return inc.Status == string(huntress.IncidentStatusNew) || inc.Status == string(huntress.IncidentStatusInProgress)
+		}))
+		writeJSON(w, http.StatusOK, huntress.SummaryReport{
+			Report: s.syntheticReport("summary", orgID, q.Get("format")),
+			Summary: map[string]interface{}{
+				"agents":         len(filter(s.data.Agents, func(a *huntress.Agent) bool { return orgID == "" || a.OrganizationID == orgID })),
+				"incidents":      len(incidents),
+				"open_incidents": open,
+			},
+		})
+	case len(seg) == 1 && seg[0] == "detailed" && r.Method == http.MethodGet:
+		orgID := q.Get("organization_id")
+		writeJSON(w, http.StatusOK, huntress.DetailedReport{
+			Report:  s.syntheticReport("detailed", orgID, q.Get("format")),
+			Content: map[string]interface{}{"incidents": s.incidentsFor(orgID)},
+		})
+	case len(seg) == 1 && seg[0] == "schedule" && r.Method == http.MethodPost:
+		var p huntress.ReportScheduleParams
+		if !decodeBody(w, r, &p) {
+			return
+		}
+		if p.Type == "" || p.Frequency == "" || len(p.Recipients) == 0 {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "type, frequency and recipients are required")
+			return
+		}
+		t := now()
+		sched := &huntress.ReportSchedule{
+			ID:             strconv.FormatInt(s.newID("schedules"), 10),
+			Type:           p.Type,
+			Format:         p.Format,
+			Frequency:      p.Frequency,
+			Recipients:     p.Recipients,
+			OrganizationID: p.OrganizationID,
+			NextRunAt:      t.Add(24 * time.Hour),
+			CreatedAt:      t,
+			UpdatedAt:      t,
+		}
+		if p.NextRunAt != nil {
+			sched.NextRunAt = *p.NextRunAt
+		}
+		s.data.Schedules = append(s.data.Schedules, sched)
+		s.audit(r, "create", "report_schedule", sched.ID)
+		writeJSON(w, http.StatusCreated, sched)
+	default:
+		i := index(s.data.Reports, seg[0], func(rep *huntress.Report) string { return rep.ID })
+		if i < 0 {
+			notFound(w, "report", seg[0])
+			return
+		}
+		rep := s.data.Reports[i]
+		switch {
+		case len(seg) == 1 && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, rep)
+		case len(seg) == 2 && (seg[1] == "download" || seg[1] == "export") && r.Method == http.MethodGet:
+			format := q.Get("format")
+			if format == "" {
+				format = rep.Format
+			}
+			writeReportFile(w, rep, format)
+		default:
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+r.URL.Path)
+		}
+	}
+}
+
+// syntheticReport describes a report computed on request.
+func (s *Server) syntheticReport(kind, orgID, format string) huntress.Report {
+	if format == "" {
+		format = "json"
+	}
+	t := now()
+	return huntress.Report{ID: kind, Type: kind, Status: "completed", Format: format, OrganizationID: orgID, CreatedAt: t, CompletedAt: t}
+}
+
+// incidentsFor returns the incidents of an organization, or all of them.
+func (s *Server) incidentsFor(orgID string) []*huntress.Incident {
+	return filter(s.data.Incidents, func(inc *huntress.Incident) bool { return orgID == "" || inc.OrganizationID == orgID })
+}
+
+// writeReportFile writes placeholder report content in format.
+func writeReportFile(w http.ResponseWriter, rep *huntress.Report, format string) {
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = fmt.Fprintf(w, "id,type,status,organization_id\n%s,%s,%s,%s\n", rep.ID, rep.Type, rep.Status, rep.OrganizationID)
+	case "json":
+		writeJSON(w, http.StatusOK, rep)
+	default:
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = fmt.Fprintf(w, "%%PDF-1.4\n%% huntresstest report %s (%s)\n", rep.ID, rep.Type)
+	}
+}
+
+// ----- Billing -----
+
+func (s *Server) handleBilling(w http.ResponseWriter, r *http.Request, seg []string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	q := r.URL.Query()
+	switch {
+	case len(seg) == 1 && seg[0] == "summary":
+		writeJSON(w, http.StatusOK, s.data.Billing)
+	case len(seg) == 1 && seg[0] == "invoices":
+		listJSON(s, w, r, s.data.Invoices)
+	case len(seg) == 2 && seg[0] == "invoices":
+		i := index(s.data.Invoices, seg[1], func(inv *huntress.Invoice) string { return inv.ID })
+		if i < 0 {
+			notFound(w, "invoice", seg[1])
+			return
+		}
+		writeJSON(w, http.StatusOK, s.data.Invoices[i])
+	case len(seg) == 1 && seg[0] == "usage":
+		orgID := q.Get("organization_id")
+		agents := filter(s.data.Agents, func(a *huntress.Agent) bool { return orgID == "" || a.OrganizationID == orgID })
+		active := filter(agents, func(a *huntress.Agent) bool { return a.Status == string(huntress.AgentStatusOnline) })
+		usage := huntress.UsageReport{
+			AccountID:      s.data.Account.ID,
+			OrganizationID: orgID,
+			AgentCount:     len(agents),
+			ActiveAgents:   len(active),
+			Usage:          map[string]int{"agents": len(agents), "incidents": len(s.incidentsFor(orgID))},
+		}
+		usage.From, _ = time.Parse(time.RFC3339, q.Get("from"))
+		usage.To, _ = time.Parse(time.RFC3339, q.Get("to"))
+		writeJSON(w, http.StatusOK, usage)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+r.URL.Path)
+	}
+}
+
+// ----- Webhooks -----
+
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request, seg []string) {
+	if len(seg) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			hooks := s.data.Webhooks
+			if hooks == nil {
+				hooks = []*huntress.Webhook{}
+			}
+			writeJSON(w, http.StatusOK, hooks)
+		case http.MethodPost:
+			var p huntress.WebhookCreateParams
+			if !decodeBody(w, r, &p) {
+				return
+			}
+			if _, err := url.ParseRequestURI(p.URL); err != nil || len(p.EventTypes) == 0 {
+				writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "a valid url and at least one event type are required")
+				return
+			}
+			t := now()
+			hook := &huntress.Webhook{ID: s.newID("webhooks"), URL: p.URL, EventTypes: p.EventTypes, Enabled: p.Enabled, CreatedAt: t, UpdatedAt: t}
+			s.data.Webhooks = append(s.data.Webhooks, hook)
+			s.audit(r, "create", "webhook", strconv.FormatInt(hook.ID, 10))
+			writeJSON(w, http.StatusCreated, hook)
+		default:
+			methodNotAllowed(w)
+		}
+		return
+	}
+	i := index(s.data.Webhooks, seg[0], func(h *huntress.Webhook) string { return strconv.FormatInt(h.ID, 10) })
+	if len(seg) > 1 || i < 0 {
+		notFound(w, "webhook", seg[0])
+		return
+	}
+	hook := s.data.Webhooks[i]
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, hook)
+	case http.MethodPut, http.MethodPatch:
+		id := hook.ID
+		if applyUpdate(w, r, hook) {
+			hook.ID, hook.UpdatedAt = id, now()
+			s.audit(r, "update", "webhook", seg[0])
+			writeJSON(w, http.StatusOK, hook)
+		}
+	case http.MethodDelete:
+		s.data.Webhooks = slices.Delete(s.data.Webhooks, i, i+1)
+		s.audit(r, "delete", "webhook", seg[0])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// ----- Audit logs -----
+
+func (s *Server) handleAuditLogs(w http.ResponseWriter, r *http.Request, seg []string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if len(seg) == 0 {
+		q := r.URL.Query()
+		start, _ := time.Parse(time.RFC3339, q.Get("start_time"))
+		end, _ := time.Parse(time.RFC3339, q.Get("end_time"))
+		logs := filter(s.data.AuditLogs, func(l *huntress.AuditLog) bool {
+			return matchParam(q, "actor", l.Actor) && matchParam(q, "action", l.Action) &&
+				matchParam(q, "resource_type", l.ResourceType) && matchParam(q, "resource_id", l.ResourceID) &&
+				(start.IsZero() || !l.Timestamp.Before(start)) && (end.IsZero() || !l.Timestamp.After(end))
+		})
+		from, to := s.page(w, r, len(logs))
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": nonNil(logs[from:to])})
+		return
+	}
+	i := index(s.data.AuditLogs, seg[0], func(l *huntress.AuditLog) string { return l.ID })
+	if len(seg) > 1 || i < 0 {
+		notFound(w, "audit log", seg[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, s.data.AuditLogs[i])
+}
+
+// audit records a mutation in the audit log, attributed to the API key.
+func (s *Server) audit(r *http.Request, action, resourceType, id string) {
+	key, _, _ := r.BasicAuth()
+	s.data.AuditLogs = append(s.data.AuditLogs, &huntress.AuditLog{
+		ID:           strconv.FormatInt(s.newID("audit-logs"), 10),
+		Timestamp:    now(),
+		Actor:        key,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   id,
+		Description:  fmt.Sprintf("%s %s %s", action, resourceType, id),
+	})
+}
+
+// ----- Helpers -----
+
+// now returns the time stamped on changes, truncated like API timestamps.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// listJSON writes the requested page of items with pagination headers.
+func listJSON[T any](s *Server, w http.ResponseWriter, r *http.Request, items []*T) {
+	start, end := s.page(w, r, len(items))
+	writeJSON(w, http.StatusOK, nonNil(items[start:end]))
+}
+
+// nonNil makes an empty list encode as [] rather than null.
+func nonNil[T any](items []*T) []*T {
+	if items == nil {
+		return []*T{}
+	}
+	return items
+}
+
+// filter returns the items keep accepts.
+func filter[T any](items []*T, keep func(*T) bool) []*T {
+	var out []*T
+	for _, item := range items {
+		if keep(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// index returns the position of the item with id, or -1.
+func index[T any](items []*T, id string, idOf func(*T) string) int {
+	return slices.IndexFunc(items, func(item *T) bool { return idOf(item) == id })
+}
+
+// matchParam reports whether the query parameter key is absent or equal
+// to value.
+func matchParam(q url.Values, key, value string) bool {
+	want := q.Get(key)
+	return want == "" || want == value
+}
+
+// hasTags reports whether tags include every tags query parameter.
+func hasTags(q url.Values, tags []string) bool {
+	for _, want := range q["tags"] {
+		if !slices.Contains(tags, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// convert copies between JSON-compatible types.
+func convert(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		return fmt.Errorf("decoding: %w", err)
+	}
+	return nil
+}
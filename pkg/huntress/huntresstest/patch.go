@@ -0,0 +1,239 @@
+package huntresstest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress"
+)
+
+// applyUpdate applies the request body to item as a JSON Patch (RFC 6902)
+// when sent as application/json-patch+json, and as a JSON Merge Patch
+// (RFC 7396) otherwise, which also covers the plain JSON updates most
+// endpoints take. It reports a 400 or 422 and returns false on failure.
+func applyUpdate[T any](w http.ResponseWriter, r *http.Request, item *T) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "reading body: "+err.Error())
+		return false
+	}
+	current, err := toDocument(item)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return false
+	}
+	var patched interface{}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == huntress.JSONPatchContentType {
+		var ops []huntress.JSONPatchOp
+		if err := json.Unmarshal(body, &ops); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid JSON patch: "+err.Error())
+			return false
+		}
+		if patched, err = applyJSONPatch(current, ops); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "INVALID_PATCH", err.Error())
+			return false
+		}
+	} else {
+		var patch interface{}
+		if err := json.Unmarshal(body, &patch); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid JSON body: "+err.Error())
+			return false
+		}
+		patched = mergePatch(current, patch)
+	}
+	data, err := json.Marshal(patched)
+	if err == nil {
+		var next T
+		if err = json.Unmarshal(data, &next); err == nil {
+			*item = next
+			return true
+		}
+	}
+	writeError(w, http.StatusUnprocessableEntity, "INVALID_PATCH", "patched resource is invalid: "+err.Error())
+	return false
+}
+
+// toDocument converts v to its generic JSON form.
+func toDocument(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encoding resource: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decoding resource: %w", err)
+	}
+	return doc, nil
+}
+
+// mergePatch applies an RFC 7396 merge patch to target.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// applyJSONPatch applies RFC 6902 add, remove, replace and test operations
+// to doc.
+func applyJSONPatch(doc interface{}, ops []huntress.JSONPatchOp) (interface{}, error) {
+	for i, op := range ops {
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		switch op.Op {
+		case "add", "replace", "remove":
+			if doc, err = patchAt(doc, tokens, op); err != nil {
+				return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+			}
+		case "test":
+			got, err := lookup(doc, tokens)
+			if err != nil || !reflect.DeepEqual(normalize(got), normalize(op.Value)) {
+				return nil, fmt.Errorf("operation %d: test failed at %s", i, op.Path)
+			}
+		default:
+			return nil, fmt.Errorf("operation %d: unsupported op %q", i, op.Op)
+		}
+	}
+	return doc, nil
+}
+
+// patchAt applies an add, replace or remove at tokens within doc and
+// returns the updated document.
+func patchAt(doc interface{}, tokens []string, op huntress.JSONPatchOp) (interface{}, error) {
+	if len(tokens) == 0 {
+		if op.Op == "remove" {
+			return nil, errors.New("cannot remove the whole document")
+		}
+		return normalize(op.Value), nil
+	}
+	key, rest := tokens[0], tokens[1:]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, exists := node[key]
+		if len(rest) > 0 {
+			if !exists && op.Op == "remove" {
+				return nil, fmt.Errorf("path segment %q not found", key)
+			}
+			updated, err := patchAt(child, rest, op)
+			if err != nil {
+				return nil, err
+			}
+			node[key] = updated
+			return node, nil
+		}
+		// Resources are rendered with omitempty, so an absent member is an
+		// empty one and replacing it is allowed
+		if op.Op == "remove" && !exists {
+			return nil, fmt.Errorf("member %q not found", key)
+		}
+		if op.Op == "remove" {
+			delete(node, key)
+		} else {
+			node[key] = normalize(op.Value)
+		}
+		return node, nil
+	case []interface{}:
+		if len(rest) == 0 && op.Op == "add" && key == "-" {
+			return append(node, normalize(op.Value)), nil
+		}
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx > len(node) || (idx == len(node) && !(op.Op == "add" && len(rest) == 0)) {
+			return nil, fmt.Errorf("invalid array index %q", key)
+		}
+		if len(rest) > 0 {
+			updated, err := patchAt(node[idx], rest, op)
+			if err != nil {
+				return nil, err
+			}
+			node[idx] = updated
+			return node, nil
+		}
+		switch op.Op {
+		case "add":
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = normalize(op.Value)
+		case "replace":
+			node[idx] = normalize(op.Value)
+		case "remove":
+			node = append(node[:idx], node[idx+1:]...)
+		}
+		return node, nil
+	case nil:
+		// Absent containers, such as custom_settings omitted when empty,
+		// are created on add or replace
+		if op.Op != "remove" {
+			return patchAt(map[string]interface{}{}, tokens, op)
+		}
+	}
+	return nil, fmt.Errorf("path segment %q not found", key)
+}
+
+// lookup returns the value at tokens within doc.
+func lookup(doc interface{}, tokens []string) (interface{}, error) {
+	for _, tok := range tokens {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			v, ok := node[tok]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", tok)
+			}
+			doc = v
+		case []interface{}:
+			idx, err := strconv.Atoi(tok)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("invalid array index %q", tok)
+			}
+			doc = node[idx]
+		default:
+			return nil, fmt.Errorf("path segment %q not found", tok)
+		}
+	}
+	return doc, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// normalize converts a patch value to its generic JSON form, so typed
+// values compare and encode like decoded ones.
+func normalize(v interface{}) interface{} {
+	doc, err := toDocument(v)
+	if err != nil {
+		return v
+	}
+	return doc
+}
@@ -0,0 +1,460 @@
+// Package recorder records HTTP interactions with the Huntress API to
+// cassette files and replays them, so tests against pkg/huntress can run
+// offline and deterministically.
+//
+// A Recorder is an http.RoundTripper. In record mode it forwards requests to
+// the real API and appends each request/response pair to the cassette, with
+// credentials, secrets and email addresses redacted. In replay mode it
+// answers from the cassette without touching the network:
+//
+//	func TestListOrganizations(t *testing.T) {
+//		rec := recorder.Start(t, "testdata/list_organizations.json")
+//		client := huntress.New(
+//			huntress.WithCredentials(os.Getenv("HUNTRESS_API_KEY"), os.Getenv("HUNTRESS_API_SECRET")),
+//			huntress.WithHTTPClient(rec.Client()),
+//		)
+//		orgs, _, err := client.Organization.List(ctx, nil)
+//		...
+//	}
+//
+// Run the tests with HUNTRESS_RECORD=1 to refresh the cassettes; CI
+// replays them.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/redact"
+)
+
+// EnvRecord is the environment variable ModeFromEnv reads.
+const EnvRecord = "HUNTRESS_RECORD"
+
+// ErrNoMatch is returned, wrapped, when replaying a request that matches no
+// interaction in the cassette.
+var ErrNoMatch = errors.New("recorder: no matching interaction")
+
+// Mode selects whether a Recorder talks to the API or to its cassette.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette, which must exist.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the API and writes the cassette on Close,
+	// replacing any previous one.
+	ModeRecord
+)
+
+// String returns the mode's name.
+func (m Mode) String() string {
+	if m == ModeRecord {
+		return "record"
+	}
+	return "replay"
+}
+
+// ModeFromEnv returns ModeRecord when HUNTRESS_RECORD is set to a true
+// value such as "1" or "true", and ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	if on, err := strconv.ParseBool(os.Getenv(EnvRecord)); err == nil && on {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request, after redaction.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response, after redaction.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Matcher reports whether a live request matches a recorded one. The live
+// request is redacted the same way as the cassette before it is compared.
+type Matcher func(live, recorded Request) bool
+
+// MatchMethod matches requests with the same HTTP method.
+func MatchMethod(live, recorded Request) bool {
+	return live.Method == recorded.Method
+}
+
+// MatchPath matches requests for the same URL path, ignoring the host so
+// cassettes replay against any base URL.
+func MatchPath(live, recorded Request) bool {
+	lu, err1 := url.Parse(live.URL)
+	ru, err2 := url.Parse(recorded.URL)
+	return err1 == nil && err2 == nil && lu.Path == ru.Path
+}
+
+// MatchQuery matches requests with the same query parameters, in any order.
+func MatchQuery(live, recorded Request) bool {
+	lu, err1 := url.Parse(live.URL)
+	ru, err2 := url.Parse(recorded.URL)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	lq, rq := lu.Query(), ru.Query()
+	return len(lq) == len(rq) && (len(lq) == 0 || reflect.DeepEqual(lq, rq))
+}
+
+// MatchBody matches requests with equal bodies. JSON bodies are compared
+// by value, so key order and whitespace do not matter.
+func MatchBody(live, recorded Request) bool {
+	if live.Body == recorded.Body {
+		return true
+	}
+	var lv, rv interface{}
+	if json.Unmarshal([]byte(live.Body), &lv) != nil || json.Unmarshal([]byte(recorded.Body), &rv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(lv, rv)
+}
+
+// DefaultMatchers match on method, path and query.
+var DefaultMatchers = []Matcher{MatchMethod, MatchPath, MatchQuery}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithMode sets the mode. The default is ModeFromEnv().
+func WithMode(m Mode) Option {
+	return func(r *Recorder) {
+		r.mode = m
+	}
+}
+
+// WithMatchers replaces DefaultMatchers. A request matches an interaction
+// when every matcher agrees.
+func WithMatchers(matchers ...Matcher) Option {
+	return func(r *Recorder) {
+		r.matchers = matchers
+	}
+}
+
+// WithStrict controls how interactions are replayed. Strict replay, the
+// default, plays each interaction at most once in recorded order among
+// matches, and Verify fails if any were left unplayed. Lenient replay lets
+// an interaction answer any number of matching requests and ignores unplayed
+// ones. Either way a request that matches nothing fails with ErrNoMatch.
+func WithStrict(strict bool) Option {
+	return func(r *Recorder) {
+		r.strict = strict
+	}
+}
+
+// WithTransport sets the transport used to reach the API in record mode.
+// The default is http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithRedactKeys redacts the values of JSON keys, query parameters and
+// headers containing any of keys, in addition to the built-in credentials,
+// secrets and email addresses.
+func WithRedactKeys(keys ...string) Option {
+	return func(r *Recorder) {
+		r.redactor.Keys = append(r.redactor.Keys, keys...)
+	}
+}
+
+// WithFilter adds a function that edits each interaction after the built-in
+// redaction and before it is saved.
+func WithFilter(fn func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.filters = append(r.filters, fn)
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette. It
+// is safe for concurrent use.
+type Recorder struct {
+	path      string
+	mode      Mode
+	matchers  []Matcher
+	strict    bool
+	transport http.RoundTripper
+	redactor  redact.Redactor
+	filters   []func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	played   []bool
+}
+
+// New creates a Recorder for the cassette at path. In replay mode the
+// cassette is loaded and must exist.
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      ModeFromEnv(),
+		matchers:  DefaultMatchers,
+		strict:    true,
+		transport: http.DefaultTransport,
+		redactor:  redact.Redactor{Emails: true},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path) // #nosec G304 -- cassette path is chosen by the test
+		if err != nil {
+			return nil, fmt.Errorf("recorder: loading cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("recorder: decoding cassette %s: %w", path, err)
+		}
+		r.played = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Start creates a Recorder for a test, failing it if the cassette cannot be
+// loaded. When the test ends the cassette is saved in record mode, and in
+// strict replay the test fails if any interaction was not played.
+func Start(t testing.TB, path string, opts ...Option) *Recorder {
+	t.Helper()
+	r, err := New(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Close(); err != nil {
+			t.Error(err)
+		}
+		if err := r.Verify(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r
+}
+
+// Mode returns the recorder's mode.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an *http.Client that sends requests through r, for use
+// with huntress.WithHTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns a copy of the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: reading request body: %w", err)
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Request: r.request(req, body),
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactor.Header(resp.Header),
+			Body:       r.body(respBody),
+		},
+	}
+	for _, fn := range r.filters {
+		fn(&in)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	live := r.request(req, body)
+	r.mu.Lock()
+	idx := r.match(live)
+	if idx < 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w for %s %s", ErrNoMatch, live.Method, live.URL)
+	}
+	r.played[idx] = true
+	rec := r.cassette.Interactions[idx].Response
+	r.mu.Unlock()
+
+	header := rec.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(rec.Body))),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// match returns the first interaction matching live, preferring unplayed
+// ones, or -1. Strict replay only considers unplayed interactions. r.mu
+// must be held.
+func (r *Recorder) match(live Request) int {
+	fallback := -1
+	for i, in := range r.cassette.Interactions {
+		if !r.matches(live, in.Request) {
+			continue
+		}
+		if !r.played[i] {
+			return i
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	if r.strict {
+		return -1
+	}
+	return fallback
+}
+
+func (r *Recorder) matches(live, recorded Request) bool {
+	for _, m := range r.matchers {
+		if !m(live, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// request converts req into its redacted, recorded form.
+func (r *Recorder) request(req *http.Request, body []byte) Request {
+	return Request{
+		Method: req.Method,
+		URL:    r.redactor.URL(req.URL),
+		Header: r.redactor.Header(req.Header),
+		Body:   r.body(body),
+	}
+}
+
+// body redacts a body for the cassette. JSON bodies have secrets and email
+// addresses replaced; other bodies only have email addresses replaced, so
+// they still replay.
+func (r *Recorder) body(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	if out, ok := r.redactor.JSON(b); ok {
+		return string(out)
+	}
+	return r.redactor.String(string(b))
+}
+
+// Verify reports interactions that strict replay did not play. It returns
+// nil in record mode and lenient replay.
+func (r *Recorder) Verify() error {
+	if r.mode != ModeReplay || !r.strict {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unplayed []string
+	for i, in := range r.cassette.Interactions {
+		if !r.played[i] {
+			unplayed = append(unplayed, in.Request.Method+" "+in.Request.URL)
+		}
+	}
+	if len(unplayed) > 0 {
+		return fmt.Errorf("recorder: %d interactions in %s were not played: %v", len(unplayed), r.path, unplayed)
+	}
+	return nil
+}
+
+// Close writes the cassette in record mode. It does nothing in replay mode.
+func (r *Recorder) Close() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&r.cassette); err != nil {
+		return fmt.Errorf("recorder: encoding cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o750); err != nil {
+		return fmt.Errorf("recorder: creating cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("recorder: writing cassette: %w", err)
+	}
+	return nil
+}
+
+// readRequestBody consumes and closes req's body, as a transport must.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer func() { _ = req.Body.Close() }()
+	return io.ReadAll(req.Body)
+}
@@ -0,0 +1,179 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress"
+)
+
+// newOrgServer serves organizations and counts the requests it receives.
+func newOrgServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations/1":
+			_, _ = w.Write([]byte(`{"id":"1","name":"Acme","contact_info":{"email":"ops@acme.example.com"},"api_token":"tok"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations":
+			_, _ = w.Write([]byte(`[{"id":"` + r.URL.Query().Get("page") + `"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func newClient(baseURL string, rec *Recorder) *huntress.Client {
+	return huntress.New(
+		huntress.WithCredentials("key", "secret"),
+		huntress.WithBaseURL(baseURL+"/v1"),
+		huntress.WithHTTPClient(rec.Client()),
+	)
+}
+
+func record(t *testing.T, path string, fn func(client *huntress.Client)) string {
+	t.Helper()
+	srv, _ := newOrgServer(t)
+	rec, err := New(path, WithMode(ModeRecord))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fn(newClient(srv.URL, rec))
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return srv.URL
+}
+
+func TestRecorder_RecordRedactsAndReplaysOffline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "org.json")
+	record(t, path, func(client *huntress.Client) {
+		org, err := client.Organization.Get(context.Background(), "1")
+		if err != nil {
+			t.Fatalf("Get while recording: %v", err)
+		}
+		if org.Name != "Acme" {
+			t.Errorf("recording altered the live response: %+v", org)
+		}
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading cassette: %v", err)
+	}
+	cassette := string(data)
+	for _, secret := range []string{"Basic ", "ops@acme.example.com", `"tok"`, "session=abc"} {
+		if strings.Contains(cassette, secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, cassette)
+		}
+	}
+
+	// The server is gone; replay must not need it
+	rec := Start(t, path, WithMode(ModeReplay))
+	client := newClient("http://127.0.0.1:1", rec)
+	org, err := client.Organization.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get while replaying: %v", err)
+	}
+	if org.ID != "1" || org.Name != "Acme" {
+		t.Errorf("replayed organization = %+v", org)
+	}
+}
+
+func TestRecorder_StrictAndLenient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "org.json")
+	record(t, path, func(client *huntress.Client) {
+		_, _ = client.Organization.Get(context.Background(), "1")
+	})
+	ctx := context.Background()
+
+	strict, err := New(path, WithMode(ModeReplay))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := strict.Verify(); err == nil {
+		t.Error("Verify passed with an unplayed interaction")
+	}
+	client := newClient("http://127.0.0.1:1", strict)
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("first Get: %v", err)
+	}
+	if _, err := client.Organization.Get(ctx, "1"); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("second strict Get error = %v, want ErrNoMatch", err)
+	}
+	if err := strict.Verify(); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+
+	lenient, err := New(path, WithMode(ModeReplay), WithStrict(false))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	client = newClient("http://127.0.0.1:1", lenient)
+	for i := 0; i < 3; i++ {
+		if _, err := client.Organization.Get(ctx, "1"); err != nil {
+			t.Fatalf("lenient Get %d: %v", i, err)
+		}
+	}
+	if _, err := client.Organization.Get(ctx, "2"); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("unrecorded Get error = %v, want ErrNoMatch", err)
+	}
+}
+
+func TestRecorder_MatchesQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pages.json")
+	list := func(client *huntress.Client, page int) string {
+		orgs, _, err := client.Organization.List(context.Background(), &huntress.ListOrganizationsParams{ListParams: huntress.ListParams{Page: page}})
+		if err != nil {
+			t.Fatalf("List page %d: %v", page, err)
+		}
+		return orgs[0].ID
+	}
+	record(t, path, func(client *huntress.Client) {
+		list(client, 1)
+		list(client, 2)
+	})
+
+	rec := Start(t, path, WithMode(ModeReplay))
+	client := newClient("http://127.0.0.1:1", rec)
+	if got := list(client, 2); got != "2" {
+		t.Errorf("page 2 replayed %q", got)
+	}
+	if got := list(client, 1); got != "1" {
+		t.Errorf("page 1 replayed %q", got)
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	a := Request{Method: "POST", URL: "http://a/v1/x?b=2&a=1", Body: `{"x":1,"y":[1,2]}`}
+	b := Request{Method: "POST", URL: "http://b/v1/x?a=1&b=2", Body: `{"y":[1,2], "x":1}`}
+	for name, m := range map[string]Matcher{"method": MatchMethod, "path": MatchPath, "query": MatchQuery, "body": MatchBody} {
+		if !m(a, b) {
+			t.Errorf("%s matcher rejected equivalent requests", name)
+		}
+	}
+	c := Request{Method: "PUT", URL: "http://a/v1/y?a=2", Body: `{"x":2}`}
+	for name, m := range map[string]Matcher{"method": MatchMethod, "path": MatchPath, "query": MatchQuery, "body": MatchBody} {
+		if m(a, c) {
+			t.Errorf("%s matcher accepted different requests", name)
+		}
+	}
+}
+
+func TestNew_MissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), WithMode(ModeReplay)); err == nil {
+		t.Error("expected an error for a missing cassette in replay mode")
+	}
+}
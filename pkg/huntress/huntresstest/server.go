@@ -0,0 +1,337 @@
+// Package huntresstest provides an in-process fake of the Huntress API for
+// end-to-end tests that cannot reach a live tenant.
+//
+// NewServer starts an httptest server that implements the endpoints used
+// by pkg/huntress (account, organizations, agents, incidents, reports,
+// billing, webhooks and audit logs) on top of in-memory storage. Lists are
+// paginated with the same X-Page and X-Total-Pages headers as the real API,
+// every request must carry the server's basic-auth credentials, and faults
+// can be injected per endpoint:
+//
+//	srv := huntresstest.NewServer(huntresstest.WithGenerated(huntresstest.GenerateConfig{
+//		Seed: 1, Organizations: 3, Agents: 30, Incidents: 10,
+//	}))
+//	defer srv.Close()
+//	client := srv.Client()
+//	for org, err := range client.Organization.All(ctx, nil) {
+//		...
+//	}
+//
+// Recorded traffic from a real tenant can be replayed instead with the
+// recorder subpackage.
+package huntresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress"
+)
+
+// Default server settings.
+const (
+	DefaultAPIKey    = "test-key"
+	DefaultAPISecret = "test-secret"
+	DefaultPerPage   = 25
+	MaxPerPage       = 500
+)
+
+// apiPrefix is the path the API is mounted under, matching the real base
+// URL's version segment.
+const apiPrefix = "/v1"
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithCredentials sets the API key and secret the server accepts. The
+// defaults are DefaultAPIKey and DefaultAPISecret.
+func WithCredentials(key, secret string) Option {
+	return func(s *Server) {
+		s.apiKey, s.apiSecret = key, secret
+	}
+}
+
+// WithPerPage sets the page size used when a list request does not ask for
+// one. The default is DefaultPerPage.
+func WithPerPage(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.perPage = n
+		}
+	}
+}
+
+// WithFixtures seeds the server with f.
+func WithFixtures(f Fixtures) Option {
+	return func(s *Server) {
+		s.seed(f)
+	}
+}
+
+// WithGenerated seeds the server with synthetic data; see Generate.
+func WithGenerated(cfg GenerateConfig) Option {
+	return func(s *Server) {
+		s.seed(Generate(cfg))
+	}
+}
+
+// Fault makes matching requests fail or slow down. See Server.InjectFault.
+type Fault struct {
+	// Method restricts the fault to one HTTP method. Empty matches all.
+	Method string
+	// Path restricts the fault to endpoints under a prefix relative to the
+	// API root, such as "/agents". Empty matches all.
+	Path string
+	// Status is the status code to respond with. Zero lets the request
+	// through after Delay.
+	Status int
+	// Body is the response body. When empty, a JSON error is sent.
+	Body string
+	// Header is added to the response, for example Retry-After on a 429.
+	Header http.Header
+	// Delay is waited before responding, unless the request is canceled.
+	Delay time.Duration
+	// Count is how many matching requests the fault applies to. Zero means
+	// every matching request until ClearFaults.
+	Count int
+}
+
+// Server is a fake Huntress API. It is safe for concurrent use.
+type Server struct {
+	srv       *httptest.Server
+	apiKey    string
+	apiSecret string
+	perPage   int
+
+	mu     sync.Mutex
+	data   Fixtures
+	nextID map[string]int64
+	faults []*Fault
+}
+
+// NewServer starts a fake API server. Call Close when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		apiKey:    DefaultAPIKey,
+		apiSecret: DefaultAPISecret,
+		perPage:   DefaultPerPage,
+		nextID:    make(map[string]int64),
+	}
+	s.seed(Fixtures{})
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.srv = httptest.NewServer(s)
+	return s
+}
+
+// URL returns the API base URL, for use with huntress.WithBaseURL.
+func (s *Server) URL() string {
+	return s.srv.URL + apiPrefix
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a huntress.Client configured for the server. opts are
+// applied after the server's credentials and base URL.
+func (s *Server) Client(opts ...huntress.Option) *huntress.Client {
+	base := []huntress.Option{
+		huntress.WithCredentials(s.apiKey, s.apiSecret),
+		huntress.WithBaseURL(s.URL()),
+		huntress.WithHTTPClient(s.srv.Client()),
+	}
+	return huntress.New(append(base, opts...)...)
+}
+
+// Seed adds f to the server's data. Items keep their IDs; items without
+// one are assigned the next free ID.
+func (s *Server) Seed(f Fixtures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seed(f)
+}
+
+// Snapshot returns a copy of the server's current data.
+func (s *Server) Snapshot() Fixtures {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneFixtures(s.data)
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were
+// added and the first match applies.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Echo the request ID so errors carry a server-reported ID
+	if id := r.Header.Get(huntress.RequestIDHeader); id != "" {
+		w.Header().Set("X-Request-Id", id)
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, apiPrefix)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown API version")
+		return
+	}
+	if key, secret, ok := r.BasicAuth(); !ok || key != s.apiKey || secret != s.apiSecret {
+		w.Header().Set("WWW-Authenticate", `Basic realm="huntress"`)
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid API credentials")
+		return
+	}
+	if f := s.takeFault(r.Method, path); f != nil {
+		if f.Delay > 0 {
+			timer := time.NewTimer(f.Delay)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		if f.Status != 0 {
+			for k, vs := range f.Header {
+				w.Header()[k] = append([]string(nil), vs...)
+			}
+			if f.Body == "" {
+				writeError(w, f.Status, "INJECTED_FAULT", http.StatusText(f.Status))
+				return
+			}
+			w.WriteHeader(f.Status)
+			_, _ = w.Write([]byte(f.Body))
+			return
+		}
+	}
+	s.route(w, r, strings.Split(strings.Trim(path, "/"), "/"))
+}
+
+// takeFault returns the first fault matching the request and uses up one
+// of its applications.
+func (s *Server) takeFault(method, path string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.faults {
+		if (f.Method != "" && f.Method != method) || !hasPathPrefix(path, f.Path) {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		cp := *f
+		return &cp
+	}
+	return nil
+}
+
+// hasPathPrefix reports whether path is prefix or lies beneath it.
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// page writes pagination headers for a list of n items and returns the
+// bounds of the requested page. The page size comes from per_page or limit.
+func (s *Server) page(w http.ResponseWriter, r *http.Request, n int) (start, end int) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	per, _ := strconv.Atoi(q.Get("per_page"))
+	if per <= 0 {
+		per, _ = strconv.Atoi(q.Get("limit"))
+	}
+	if per <= 0 {
+		per = s.perPage
+	}
+	if per > MaxPerPage {
+		per = MaxPerPage
+	}
+	pages := (n + per - 1) / per
+	if pages < 1 {
+		pages = 1
+	}
+	h := w.Header()
+	h.Set("X-Page", strconv.Itoa(page))
+	h.Set("X-Per-Page", strconv.Itoa(per))
+	h.Set("X-Total-Pages", strconv.Itoa(pages))
+	h.Set("X-Total-Count", strconv.Itoa(n))
+	start = min((page-1)*per, n)
+	return start, min(start+per, n)
+}
+
+// newID returns the next ID for kind. s.mu must be held.
+func (s *Server) newID(kind string) int64 {
+	s.nextID[kind]++
+	return s.nextID[kind]
+}
+
+// reserveID makes sure later IDs for kind are above id. s.mu must be held.
+func (s *Server) reserveID(kind, id string) {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > s.nextID[kind] {
+		s.nextID[kind] = n
+	}
+}
+
+// writeJSON writes v with status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the API's error envelope.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"code": code, "message": message})
+}
+
+// notFoundCodes holds the API's not-found codes that are not the upper
+// snake case form of the resource kind.
+var notFoundCodes = map[string]string{"organization": "ORG_NOT_FOUND"}
+
+// notFound reports a missing resource with the API's resource-specific
+// code, such as AGENT_NOT_FOUND.
+func notFound(w http.ResponseWriter, kind, id string) {
+	code, ok := notFoundCodes[kind]
+	if !ok {
+		code = strings.ToUpper(strings.ReplaceAll(kind, " ", "_")) + "_NOT_FOUND"
+	}
+	writeError(w, http.StatusNotFound, code, fmt.Sprintf("%s %s not found", kind, id))
+}
+
+// decodeBody decodes a JSON request body into v, reporting a 400 on
+// failure.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// methodNotAllowed reports an unsupported method on an existing endpoint.
+func methodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+}
@@ -0,0 +1,268 @@
+package huntresstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/greysquirr3l/bishoujo-huntress/pkg/huntress"
+)
+
+func TestGenerate_Deterministic(t *testing.T) {
+	cfg := GenerateConfig{Seed: 7, Organizations: 3, Agents: 10, Incidents: 6, Invoices: 2}
+	a, b := Generate(cfg), Generate(cfg)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("Generate returned different data for the same config")
+	}
+	if len(a.Organizations) != 3 || len(a.Agents) != 10 || len(a.Incidents) != 6 || len(a.Invoices) != 2 {
+		t.Fatalf("unexpected sizes: %d orgs, %d agents, %d incidents, %d invoices",
+			len(a.Organizations), len(a.Agents), len(a.Incidents), len(a.Invoices))
+	}
+	for _, inc := range a.Incidents {
+		agent := a.Agents[atoi(t, inc.AgentID)-1]
+		if agent.OrganizationID != inc.OrganizationID {
+			t.Errorf("incident %s belongs to org %s but its agent to org %s", inc.ID, inc.OrganizationID, agent.OrganizationID)
+		}
+	}
+	if reflect.DeepEqual(a, Generate(GenerateConfig{Seed: 8, Organizations: 3, Agents: 10, Incidents: 6, Invoices: 2})) {
+		t.Error("different seeds produced identical data")
+	}
+}
+
+func TestServer_PaginatesAndFilters(t *testing.T) {
+	srv := NewServer(WithPerPage(2), WithGenerated(GenerateConfig{Seed: 1, Organizations: 5, Agents: 9}))
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	orgs, page, err := client.Organization.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(orgs) != 2 || page.TotalPages != 3 || page.TotalItems != 5 || page.CurrentPage != 1 {
+		t.Errorf("first page = %d orgs, %+v", len(orgs), page)
+	}
+
+	var all int
+	for _, err := range client.Organization.All(ctx, nil) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		all++
+	}
+	if all != 5 {
+		t.Errorf("All yielded %d organizations, want 5", all)
+	}
+
+	agents, _, err := client.Agent.List(ctx, &huntress.AgentListOptions{ListParams: huntress.ListParams{PerPage: 50}, OrganizationID: 2})
+	if err != nil {
+		t.Fatalf("Agent.List: %v", err)
+	}
+	if len(agents) != 2 {
+		t.Errorf("org 2 has %d agents, want 2", len(agents))
+	}
+	for _, a := range agents {
+		if a.OrganizationID != "2" {
+			t.Errorf("agent %s from org %s in org 2's list", a.ID, a.OrganizationID)
+		}
+	}
+}
+
+func TestServer_RequiresCredentials(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client(huntress.WithCredentials("wrong", "creds"))
+	_, err := client.Account.Get(context.Background())
+	var apiErr *huntress.APIError
+	if !huntress.IsAPIError(err) || !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusUnauthorized {
+		t.Fatalf("error = %v, want a 401 APIError", err)
+	}
+}
+
+func TestServer_OrganizationLifecycle(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	org, err := client.Organization.Create(ctx, &huntress.OrganizationCreateParams{Name: "Acme", Tags: []string{"vip"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	updated, err := client.Organization.Update(ctx, org.ID, &huntress.OrganizationUpdateParams{Name: "Acme Corp"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Name != "Acme Corp" || updated.ID != org.ID || !reflect.DeepEqual(updated.Tags, []string{"vip"}) {
+		t.Errorf("updated organization = %+v", updated)
+	}
+	if err := client.Organization.Delete(ctx, org.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := client.Organization.Get(ctx, org.ID); !huntress.IsNotFoundError(err) {
+		t.Errorf("Get after delete error = %v, want not found", err)
+	}
+
+	logs, _, err := client.AuditLog.List(ctx, &huntress.AuditLogListParams{})
+	if err != nil {
+		t.Fatalf("AuditLog.List: %v", err)
+	}
+	var actions []string
+	for _, l := range logs {
+		actions = append(actions, l.Action)
+	}
+	if want := []string{"create", "update", "delete"}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("audit actions = %v, want %v", actions, want)
+	}
+}
+
+func TestServer_NotFoundMatchesSentinels(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	if _, err := client.Organization.Get(ctx, "999999"); !errors.Is(err, huntress.ErrOrgNotFound) {
+		t.Errorf("Organization.Get error = %v, want ErrOrgNotFound", err)
+	}
+	if _, err := client.Agent.Get(ctx, "999999"); !errors.Is(err, huntress.ErrAgentNotFound) {
+		t.Errorf("Agent.Get error = %v, want ErrAgentNotFound", err)
+	}
+	var apiErr *huntress.APIError
+	if _, err := client.Incident.Get(ctx, "999999"); !errors.As(err, &apiErr) || apiErr.Code() != "INCIDENT_NOT_FOUND" {
+		t.Errorf("Incident.Get error = %v, want code INCIDENT_NOT_FOUND", err)
+	}
+}
+
+func TestServer_AgentPatch(t *testing.T) {
+	srv := NewServer(WithGenerated(GenerateConfig{Seed: 1, Organizations: 1, Agents: 1}))
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	tags := []string{"prod"}
+	value := "on"
+	agent, err := client.Agent.Patch(ctx, "1", &huntress.AgentUpdateParams{
+		Tags:           &tags,
+		CustomSettings: map[string]*string{"a/b": &value},
+	}, huntress.JSONPatchFormat)
+	if err != nil {
+		t.Fatalf("JSON patch: %v", err)
+	}
+	if !reflect.DeepEqual(agent.Tags, tags) || agent.Settings.CustomSettings["a/b"] != "on" {
+		t.Errorf("after JSON patch: tags %v, custom %v", agent.Tags, agent.Settings.CustomSettings)
+	}
+
+	agent, err = client.Agent.Patch(ctx, "1", &huntress.AgentUpdateParams{
+		CustomSettings: map[string]*string{"a/b": nil},
+	}, huntress.MergePatch)
+	if err != nil {
+		t.Fatalf("merge patch: %v", err)
+	}
+	if _, ok := agent.Settings.CustomSettings["a/b"]; ok || !agent.Settings.AutoUpdate {
+		t.Errorf("after merge patch: settings %+v", agent.Settings)
+	}
+}
+
+func TestServer_Faults(t *testing.T) {
+	srv := NewServer(WithGenerated(GenerateConfig{Seed: 1, Organizations: 1}))
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	srv.InjectFault(Fault{Path: "/organizations", Status: http.StatusServiceUnavailable, Count: 1})
+	if _, err := client.Organization.Get(ctx, "1"); !huntress.IsTemporary(err) {
+		t.Errorf("faulted Get error = %v, want a temporary error", err)
+	}
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Errorf("Get after the fault was used up: %v", err)
+	}
+
+	srv.InjectFault(Fault{Method: http.MethodGet, Status: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"7"}}})
+	_, err := client.Account.Get(ctx)
+	var rl *huntress.RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter() != 7 {
+		t.Errorf("error = %v, want a RateLimitError with RetryAfter 7", err)
+	}
+	srv.ClearFaults()
+	if _, err := client.Account.Get(ctx); err != nil {
+		t.Errorf("Get after ClearFaults: %v", err)
+	}
+}
+
+func TestServer_IncidentsReportsBilling(t *testing.T) {
+	srv := NewServer(WithGenerated(GenerateConfig{Seed: 3, Organizations: 2, Agents: 4, Incidents: 4, Invoices: 3}))
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	inc, err := client.Incident.UpdateStatus(ctx, "1", string(huntress.IncidentStatusResolved))
+	if err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	if inc.Status != "resolved" || inc.ResolvedAt.IsZero() {
+		t.Errorf("incident after resolve = %+v", inc)
+	}
+	if inc, err = client.Incident.Assign(ctx, "1", "user-9"); err != nil || inc.AssignedTo != "user-9" {
+		t.Errorf("Assign = %+v, %v", inc, err)
+	}
+
+	rep, err := client.Report.Generate(ctx, &huntress.ReportGenerateInput{Type: "incidents", Format: "csv"})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	data, err := client.Report.Download(ctx, rep.ID, "csv")
+	if err != nil || !strings.HasPrefix(string(data), "id,type") {
+		t.Errorf("Download = %q, %v", data, err)
+	}
+
+	invoices, page, err := client.Billing.ListInvoices(ctx, nil)
+	if err != nil || len(invoices) != 3 || page.TotalItems != 3 {
+		t.Errorf("ListInvoices = %d invoices, %+v, %v", len(invoices), page, err)
+	}
+	usage, err := client.Billing.GetUsage(ctx, &huntress.UsageParams{OrganizationID: "1"})
+	if err != nil || usage.AgentCount != 2 {
+		t.Errorf("GetUsage = %+v, %v", usage, err)
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	f := Fixtures{Organizations: []*huntress.Organization{{ID: "42", Name: "Fixture Org"}}}
+	data, _ := json.Marshal(f)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFixtures(path)
+	if err != nil {
+		t.Fatalf("LoadFixtures: %v", err)
+	}
+	srv := NewServer(WithFixtures(loaded))
+	defer srv.Close()
+	client := srv.Client()
+
+	org, err := client.Organization.Get(context.Background(), "42")
+	if err != nil || org.Name != "Fixture Org" {
+		t.Fatalf("Get = %+v, %v", org, err)
+	}
+	created, err := client.Organization.Create(context.Background(), &huntress.OrganizationCreateParams{Name: "Next"})
+	if err != nil || created.ID != "43" {
+		t.Errorf("Create after seeding = %+v, %v; want ID 43", created, err)
+	}
+}
+
+func atoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatalf("atoi(%q): %v", s, err)
+	}
+	return n
+}
@@ -4,6 +4,7 @@ package huntress
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
 	"os"
 )
@@ -50,6 +51,32 @@ func (s *incidentService) List(ctx context.Context, params *IncidentListOptions)
 	return incidents, pagination, nil
 }
 
+// All iterates over every incident matching params, fetching pages lazily
+func (s *incidentService) All(ctx context.Context, params *IncidentListOptions) iter.Seq2[*Incident, error] {
+	var opts IncidentListOptions
+	if params != nil {
+		opts = *params
+	}
+	return paginate(ctx, opts.Page, opts.PerPage, func(ctx context.Context, page int) ([]*Incident, *Pagination, error) {
+		opts.Page = page
+		return s.List(ctx, &opts)
+	}, func(i *Incident) string { return i.ID })
+}
+
+// AllParallel iterates over every incident matching params, fetching pages
+// concurrently once the first response reports the total page count
+func (s *incidentService) AllParallel(ctx context.Context, params *IncidentListOptions, opts *PrefetchOptions) iter.Seq2[*Incident, error] {
+	var base IncidentListOptions
+	if params != nil {
+		base = *params
+	}
+	return prefetch(ctx, base.Page, base.PerPage, func(ctx context.Context, page int) ([]*Incident, *Pagination, error) {
+		p := base
+		p.Page = page
+		return s.List(ctx, &p)
+	}, func(i *Incident) string { return i.ID }, opts)
+}
+
 // UpdateStatus updates the status of an incident
 func (s *incidentService) UpdateStatus(ctx context.Context, id string, status string) (*Incident, error) {
 	path := fmt.Sprintf("/incidents/%s/status", id)
@@ -0,0 +1,100 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// pageFetcher retrieves a single page of results.
+type pageFetcher[T any] func(ctx context.Context, page int) ([]*T, *Pagination, error)
+
+// paginate returns an iterator that lazily walks every page produced by fetch,
+// starting at startPage. Items already seen (by id) are skipped, so records
+// that shift between pages while iterating are yielded only once. Iteration
+// stops after the last page, on an empty page, or when ctx is canceled. When
+// the total page count is unknown, a page that contains no new items also
+// ends iteration, guarding against APIs that repeat their last page. Errors
+// are yielded once and end iteration.
+func paginate[T any](ctx context.Context, startPage, perPage int, fetch pageFetcher[T], id func(*T) string) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		if startPage < 1 {
+			startPage = 1
+		}
+		seen := make(map[string]struct{})
+		for page := startPage; ; page++ {
+			if err := ctx.Err(); err != nil {
+				yield(nil, fmt.Errorf("iterating page %d: %w", page, err))
+				return
+			}
+			items, pagination, err := fetch(ctx, page)
+			if err != nil {
+				yield(nil, fmt.Errorf("fetching page %d: %w", page, err))
+				return
+			}
+
+			fresh := 0
+			for _, item := range items {
+				if item == nil {
+					continue
+				}
+				if key := id(item); key != "" {
+					if _, dup := seen[key]; dup {
+						continue
+					}
+					seen[key] = struct{}{}
+				}
+				fresh++
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if len(items) == 0 || isLastPage(page, len(items), perPage, pagination) {
+				return
+			}
+			// Only stop on duplicates when the page count cannot tell us
+			// more remain; shifted data can fill a whole page with them
+			if fresh == 0 && (pagination == nil || pagination.TotalPages <= 0) {
+				return
+			}
+		}
+	}
+}
+
+// isLastPage decides whether page is the final page, preferring the
+// pagination headers and falling back to a short page.
+func isLastPage(page, count, perPage int, p *Pagination) bool {
+	if p != nil && p.PerPage > 0 {
+		perPage = p.PerPage
+	}
+	if p != nil && p.TotalPages > 0 {
+		current := page
+		if p.CurrentPage > 0 {
+			current = p.CurrentPage
+		}
+		return current >= p.TotalPages
+	}
+	return perPage > 0 && count < perPage
+}
+
+// Collect drains seq into a slice. It stops after maxItems items when
+// maxItems is positive. On error it returns the items gathered so far
+// together with the error.
+func Collect[T any](seq iter.Seq2[*T, error], maxItems int) ([]*T, error) {
+	var out []*T
+	if maxItems > 0 {
+		out = make([]*T, 0, maxItems)
+	}
+	for item, err := range seq {
+		if err != nil {
+			return out, err
+		}
+		out = append(out, item)
+		if maxItems > 0 && len(out) >= maxItems {
+			break
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// newPagedServer serves /agents in pages of the given IDs, reporting the
+// page count through X-Page and X-Total-Pages.
+func newPagedServer(t *testing.T, pages [][]string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		w.Header().Set("X-Page", strconv.Itoa(page))
+		w.Header().Set("X-Total-Pages", strconv.Itoa(len(pages)))
+		body := "["
+		if page <= len(pages) {
+			for i, id := range pages[page-1] {
+				if i > 0 {
+					body += ","
+				}
+				body += fmt.Sprintf(`{"id":%q}`, id)
+			}
+		}
+		body += "]"
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func agentIDs(agents []*Agent) []string {
+	ids := make([]string, 0, len(agents))
+	for _, a := range agents {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func TestAgentService_All_WalksEveryPage(t *testing.T) {
+	srv, calls := newPagedServer(t, [][]string{{"a1", "a2"}, {"a3", "a4"}, {"a5"}})
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+
+	agents, err := Collect(client.Agent.All(context.Background(), nil), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(agentIDs(agents)); got != "[a1 a2 a3 a4 a5]" {
+		t.Errorf("unexpected agents: %s", got)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+}
+
+func TestAgentService_All_SkipsShiftedDuplicates(t *testing.T) {
+	srv, _ := newPagedServer(t, [][]string{{"a1", "a2"}, {"a2", "a3"}})
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+
+	agents, err := Collect(client.Agent.All(context.Background(), nil), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(agentIDs(agents)); got != "[a1 a2 a3]" {
+		t.Errorf("unexpected agents: %s", got)
+	}
+}
+
+func TestAgentService_All_ContinuesPastDuplicatePage(t *testing.T) {
+	srv, calls := newPagedServer(t, [][]string{{"a1", "a2"}, {"a1", "a2"}, {"a3"}})
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+
+	agents, err := Collect(client.Agent.All(context.Background(), nil), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(agentIDs(agents)); got != "[a1 a2 a3]" {
+		t.Errorf("unexpected agents: %s", got)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+}
+
+func TestPaginate_StopsOnDuplicatePageWithoutTotal(t *testing.T) {
+	calls := 0
+	fetch := func(_ context.Context, _ int) ([]*Agent, *Pagination, error) {
+		calls++
+		return []*Agent{{ID: "a1"}, {ID: "a2"}}, nil, nil
+	}
+	items, err := Collect(paginate(context.Background(), 1, 2, fetch, func(a *Agent) string { return a.ID }), 0)
+	if err != nil || len(items) != 2 || calls != 2 {
+		t.Errorf("got %d items after %d fetches (err %v), want 2 after 2", len(items), calls, err)
+	}
+}
+
+func TestAgentService_All_StopsOnBreak(t *testing.T) {
+	srv, calls := newPagedServer(t, [][]string{{"a1", "a2"}, {"a3", "a4"}, {"a5"}})
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+
+	agents, err := Collect(client.Agent.All(context.Background(), nil), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(agents) != 3 {
+		t.Errorf("expected 3 agents, got %d", len(agents))
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestPaginate_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fetch := func(_ context.Context, page int) ([]*Agent, *Pagination, error) {
+		cancel()
+		return []*Agent{{ID: strconv.Itoa(page)}}, &Pagination{TotalPages: 5}, nil
+	}
+	items, err := Collect(paginate(ctx, 1, 0, fetch, func(a *Agent) string { return a.ID }), 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("expected the first page to be yielded, got %d items", len(items))
+	}
+}
+
+func TestPaginate_PropagatesFetchError(t *testing.T) {
+	boom := errors.New("boom")
+	fetch := func(_ context.Context, _ int) ([]*Agent, *Pagination, error) {
+		return nil, nil, boom
+	}
+	_, err := Collect(paginate(context.Background(), 1, 0, fetch, func(a *Agent) string { return a.ID }), 0)
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected wrapped fetch error, got %v", err)
+	}
+}
+
+func TestIsLastPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    int
+		count   int
+		perPage int
+		p       *Pagination
+		want    bool
+	}{
+		{"total pages reached", 3, 10, 10, &Pagination{TotalPages: 3}, true},
+		{"more pages", 2, 10, 10, &Pagination{TotalPages: 3}, false},
+		{"current page header wins", 1, 10, 10, &Pagination{CurrentPage: 3, TotalPages: 3}, true},
+		{"short page", 1, 4, 10, &Pagination{}, true},
+		{"full page without totals", 1, 10, 10, nil, false},
+		{"unknown page size", 1, 4, 0, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLastPage(tt.page, tt.count, tt.perPage, tt.p); got != tt.want {
+				t.Errorf("isLastPage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
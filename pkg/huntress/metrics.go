@@ -0,0 +1,141 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives a measurement for every API call made through
+// Client.Do, including calls answered from the cache. Dry-run calls are not
+// reported because they never reach the API. ObserveRequest is called
+// synchronously once the call completes and may be called concurrently.
+type Metrics interface {
+	ObserveRequest(m RequestMetrics)
+}
+
+// MetricsFunc adapts an ordinary function to the Metrics interface.
+type MetricsFunc func(m RequestMetrics)
+
+// ObserveRequest calls f(m).
+func (f MetricsFunc) ObserveRequest(m RequestMetrics) {
+	f(m)
+}
+
+// RequestMetrics describes one API call.
+type RequestMetrics struct {
+	// Method is the HTTP method.
+	Method string
+	// Endpoint is the request path relative to the base URL with IDs
+	// replaced by placeholders, such as "/agents/{id}". See EndpointTemplate.
+	Endpoint string
+	// StatusCode is the final response status, or 0 if no response was
+	// received.
+	StatusCode int
+	// Err is the error returned by Client.Do, if any.
+	Err error
+	// Duration is the time the whole call took, including retries and
+	// rate-limit waits.
+	Duration time.Duration
+	// Attempts is the number of requests sent to the API. It is 0 when the
+	// call was answered from the cache or failed before anything was sent.
+	Attempts int
+	// Retries is the number of attempts after the first.
+	Retries int
+	// Cache is the cache outcome (CacheHit, CacheMiss, CacheRevalidated or
+	// CacheStale), or "" if the call did not go through the cache.
+	Cache string
+	// RateLimitWait is the total time spent waiting for the rate limiter.
+	RateLimitWait time.Duration
+	// RequestBytes is the size of the request body.
+	RequestBytes int64
+	// ResponseBytes is the size of the response body read by the client.
+	ResponseBytes int64
+}
+
+// EndpointTemplate replaces the ID segments of an endpoint path with
+// "{id}", so "/organizations/42/agents/7" becomes
+// "/organizations/{id}/agents/{id}". A segment is taken to be an ID when it
+// contains a digit, which holds for the API's numeric IDs and UUIDs while
+// leaving resource names such as "audit-logs" alone. Query strings are
+// dropped.
+func EndpointTemplate(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.ContainsAny(seg, "0123456789") {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// callStats accumulates what the pipeline stages learn about one call. It
+// travels in the request context, and stages update it atomically because
+// coalesced and background requests may run on other goroutines.
+type callStats struct {
+	attempts      atomic.Int64
+	rateLimitWait atomic.Int64 // nanoseconds
+}
+
+// callStatsCtxKey carries the *callStats of the current call.
+type callStatsCtxKey struct{}
+
+func withCallStats(ctx context.Context, s *callStats) context.Context {
+	return context.WithValue(ctx, callStatsCtxKey{}, s)
+}
+
+func callStatsFromContext(ctx context.Context) *callStats {
+	s, _ := ctx.Value(callStatsCtxKey{}).(*callStats)
+	return s
+}
+
+// attemptStage counts the requests that reach the transport.
+func (c *Client) attemptStage(next Doer) Doer {
+	if c.metrics == nil && c.tracer == nil {
+		return next
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if s := callStatsFromContext(req.Context()); s != nil {
+			s.attempts.Add(1)
+		}
+		return next.Do(req)
+	})
+}
+
+// observe runs do and reports the call to the client's Metrics.
+func (c *Client) observe(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	stats := &callStats{}
+	start := time.Now()
+	resp, err := c.do(withCallStats(ctx, stats), req, v)
+	if resp != nil && resp.Header.Get(DryRunHeader) != "" {
+		return resp, err
+	}
+	m := RequestMetrics{
+		Method:        req.Method,
+		Endpoint:      EndpointTemplate(c.endpointPath(req.URL)),
+		Err:           err,
+		Duration:      time.Since(start),
+		Attempts:      int(stats.attempts.Load()),
+		RateLimitWait: time.Duration(stats.rateLimitWait.Load()),
+		RequestBytes:  max(req.ContentLength, 0),
+	}
+	if m.Attempts > 1 {
+		m.Retries = m.Attempts - 1
+	}
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+		m.Cache = CacheStatus(resp)
+		m.ResponseBytes = max(resp.ContentLength, 0)
+	}
+	c.metrics.ObserveRequest(m)
+	return resp, err
+}
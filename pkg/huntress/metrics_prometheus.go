@@ -0,0 +1,234 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPrometheusNamespace prefixes the metric names of a
+// PrometheusCollector unless PrometheusConfig.Namespace is set.
+const DefaultPrometheusNamespace = "huntress"
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the request
+// duration histogram.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusConfig configures a PrometheusCollector. Zero fields use the
+// defaults.
+type PrometheusConfig struct {
+	// Namespace prefixes every metric name. Defaults to
+	// DefaultPrometheusNamespace.
+	Namespace string
+	// Buckets are the upper bounds of the request duration histogram in
+	// seconds, in increasing order. Defaults to DefaultLatencyBuckets.
+	Buckets []float64
+}
+
+// PrometheusCollector is a Metrics that aggregates API calls and serves
+// them in the Prometheus text exposition format, without depending on the
+// Prometheus client library. Register it with WithMetrics and mount it as
+// an http.Handler:
+//
+//	metrics := huntress.NewPrometheusCollector(huntress.PrometheusConfig{})
+//	client := huntress.New(huntress.WithMetrics(metrics), ...)
+//	http.Handle("/metrics", metrics)
+//
+// Series are labeled by method and endpoint template, so each service's
+// share of the API budget can be read off the request and retry counters.
+// It is safe for concurrent use.
+type PrometheusCollector struct {
+	namespace string
+	buckets   []float64
+
+	mu     sync.Mutex
+	series map[endpointKey]*endpointSeries
+}
+
+// endpointKey identifies the series of one method and endpoint template.
+type endpointKey struct {
+	method   string
+	endpoint string
+}
+
+// endpointSeries holds the aggregated measurements of one endpoint.
+type endpointSeries struct {
+	requests      map[string]uint64 // by status
+	cache         map[string]uint64 // by outcome
+	retries       uint64
+	rateLimitWait float64 // seconds
+	requestBytes  int64
+	responseBytes int64
+	buckets       []uint64 // cumulative counts are computed on output
+	durationSum   float64
+	durationCount uint64
+}
+
+// NewPrometheusCollector creates a PrometheusCollector.
+func NewPrometheusCollector(cfg PrometheusConfig) *PrometheusCollector {
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultPrometheusNamespace
+	}
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = DefaultLatencyBuckets
+	}
+	buckets := append([]float64(nil), cfg.Buckets...)
+	sort.Float64s(buckets)
+	return &PrometheusCollector{
+		namespace: cfg.Namespace,
+		buckets:   buckets,
+		series:    make(map[endpointKey]*endpointSeries),
+	}
+}
+
+// ObserveRequest implements Metrics.
+func (p *PrometheusCollector) ObserveRequest(m RequestMetrics) {
+	status := "error"
+	if m.StatusCode != 0 {
+		status = strconv.Itoa(m.StatusCode)
+	}
+	seconds := m.Duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := endpointKey{method: m.Method, endpoint: m.Endpoint}
+	s, ok := p.series[key]
+	if !ok {
+		s = &endpointSeries{
+			requests: make(map[string]uint64),
+			cache:    make(map[string]uint64),
+			buckets:  make([]uint64, len(p.buckets)),
+		}
+		p.series[key] = s
+	}
+	s.requests[status]++
+	if m.Cache != "" {
+		s.cache[strings.ToLower(m.Cache)]++
+	}
+	s.retries += uint64(max(m.Retries, 0))
+	s.rateLimitWait += m.RateLimitWait.Seconds()
+	s.requestBytes += m.RequestBytes
+	s.responseBytes += m.ResponseBytes
+	if i := sort.SearchFloat64s(p.buckets, seconds); i < len(s.buckets) {
+		s.buckets[i]++
+	}
+	s.durationSum += seconds
+	s.durationCount++
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (p *PrometheusCollector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+// Series are sorted, so the output is stable.
+func (p *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]endpointKey, 0, len(p.series))
+	for k := range p.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	family := func(name, typ, help string, each func(s *endpointSeries, labels string)) {
+		name = p.namespace + "_" + name
+		fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, k := range keys {
+			each(p.series[k], fmt.Sprintf(`method="%s",endpoint="%s"`, escapeLabel(k.method), escapeLabel(k.endpoint)))
+		}
+	}
+	sample := func(name, labels, value string) {
+		fmt.Fprintf(cw, "%s_%s{%s} %s\n", p.namespace, name, labels, value)
+	}
+
+	family("requests_total", "counter", "API calls by method, endpoint and final status.", func(s *endpointSeries, labels string) {
+		for _, status := range sortedKeys(s.requests) {
+			sample("requests_total", labels+`,status="`+status+`"`, strconv.FormatUint(s.requests[status], 10))
+		}
+	})
+	family("request_duration_seconds", "histogram", "API call latency, including retries and rate-limit waits.", func(s *endpointSeries, labels string) {
+		var cumulative uint64
+		for i, le := range p.buckets {
+			cumulative += s.buckets[i]
+			sample("request_duration_seconds_bucket", labels+`,le="`+formatFloat(le)+`"`, strconv.FormatUint(cumulative, 10))
+		}
+		sample("request_duration_seconds_bucket", labels+`,le="+Inf"`, strconv.FormatUint(s.durationCount, 10))
+		sample("request_duration_seconds_sum", labels, formatFloat(s.durationSum))
+		sample("request_duration_seconds_count", labels, strconv.FormatUint(s.durationCount, 10))
+	})
+	family("request_retries_total", "counter", "Attempts sent after the first.", func(s *endpointSeries, labels string) {
+		sample("request_retries_total", labels, strconv.FormatUint(s.retries, 10))
+	})
+	family("cache_requests_total", "counter", "Cached calls by cache outcome.", func(s *endpointSeries, labels string) {
+		for _, outcome := range sortedKeys(s.cache) {
+			sample("cache_requests_total", labels+`,outcome="`+outcome+`"`, strconv.FormatUint(s.cache[outcome], 10))
+		}
+	})
+	family("rate_limit_wait_seconds_total", "counter", "Time spent waiting for the rate limiter.", func(s *endpointSeries, labels string) {
+		sample("rate_limit_wait_seconds_total", labels, formatFloat(s.rateLimitWait))
+	})
+	family("request_bytes_total", "counter", "Request body bytes sent.", func(s *endpointSeries, labels string) {
+		sample("request_bytes_total", labels, strconv.FormatInt(s.requestBytes, 10))
+	})
+	family("response_bytes_total", "counter", "Response body bytes received.", func(s *endpointSeries, labels string) {
+		sample("response_bytes_total", labels, strconv.FormatInt(s.responseBytes, 10))
+	})
+
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// countingWriter counts the bytes written and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// escapeLabel escapes a label value for the text exposition format.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// formatFloat formats a sample value or bucket bound.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
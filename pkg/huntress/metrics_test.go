@@ -0,0 +1,171 @@
+package huntress
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/agents":                       "/agents",
+		"/agents/42":                    "/agents/{id}",
+		"/organizations/7/agents/9":     "/organizations/{id}/agents/{id}",
+		"/reports/3f2a-b1c9/download":   "/reports/{id}/download",
+		"/audit-logs?page=2":            "/audit-logs",
+		"/account/stats":                "/account/stats",
+		"/incidents/abc123/assign":      "/incidents/{id}/assign",
+		"/billing/invoices/INV-00001":   "/billing/invoices/{id}",
+		"/organizations/12/agents?x=1/": "/organizations/{id}/agents",
+	}
+	for path, want := range tests {
+		if got := EndpointTemplate(path); got != want {
+			t.Errorf("EndpointTemplate(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// sleepyLimiter is a RateLimiter that makes every request wait.
+type sleepyLimiter struct{ d time.Duration }
+
+func (l sleepyLimiter) Wait(context.Context) error     { time.Sleep(l.d); return nil }
+func (l sleepyLimiter) Reserve() (bool, time.Duration) { return true, 0 }
+
+func TestClient_Metrics(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"7","hostname":"web-1"}`))
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var got []RequestMetrics
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL+"/v1"),
+		WithCacheTTL(time.Minute),
+		WithRetryConfig(2, time.Millisecond, time.Millisecond),
+		WithRateLimiter(sleepyLimiter{d: 5 * time.Millisecond}),
+		WithMetrics(MetricsFunc(func(m RequestMetrics) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, m)
+		})),
+	)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Agent.Get(ctx, "7"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("observed %d calls, want 2", len(got))
+	}
+	first, second := got[0], got[1]
+	if first.Method != http.MethodGet || first.Endpoint != "/agents/{id}" || first.StatusCode != http.StatusOK {
+		t.Errorf("first call = %+v", first)
+	}
+	if first.Attempts != 2 || first.Retries != 1 || first.Cache != CacheMiss {
+		t.Errorf("first call attempts %d, retries %d, cache %q; want 2, 1, MISS", first.Attempts, first.Retries, first.Cache)
+	}
+	if first.RateLimitWait < 10*time.Millisecond || first.Duration < first.RateLimitWait {
+		t.Errorf("first call waited %s of %s, want at least 10ms for two attempts", first.RateLimitWait, first.Duration)
+	}
+	if first.ResponseBytes != int64(len(`{"id":"7","hostname":"web-1"}`)) {
+		t.Errorf("first call response bytes = %d", first.ResponseBytes)
+	}
+	if second.Attempts != 0 || second.Cache != CacheHit || second.RateLimitWait != 0 {
+		t.Errorf("cached call = %+v, want no attempts and a cache hit", second)
+	}
+}
+
+func TestClient_Metrics_ErrorsAndDryRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"AGENT_NOT_FOUND"}`))
+	}))
+	defer srv.Close()
+
+	var got []RequestMetrics
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithMetrics(MetricsFunc(func(m RequestMetrics) {
+		got = append(got, m)
+	})))
+	ctx := context.Background()
+	_, err := client.Agent.Get(ctx, "1")
+	if !IsNotFoundError(err) {
+		t.Fatalf("error = %v, want not found", err)
+	}
+	if _, err := client.Organization.Update(WithRequestOptions(ctx, DryRun(true)), "1", &OrganizationUpdateParams{Name: "x"}); err != nil {
+		t.Fatalf("dry-run Update: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("observed %d calls, want only the real one", len(got))
+	}
+	if got[0].StatusCode != http.StatusNotFound || !IsNotFoundError(got[0].Err) || got[0].Attempts != 1 {
+		t.Errorf("failed call = %+v", got[0])
+	}
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	p := NewPrometheusCollector(PrometheusConfig{Namespace: "test", Buckets: []float64{0.5, 0.1}})
+	p.ObserveRequest(RequestMetrics{Method: "GET", Endpoint: "/agents/{id}", StatusCode: 200, Duration: 50 * time.Millisecond,
+		Attempts: 3, Retries: 2, Cache: CacheMiss, RateLimitWait: 250 * time.Millisecond, ResponseBytes: 100})
+	p.ObserveRequest(RequestMetrics{Method: "GET", Endpoint: "/agents/{id}", StatusCode: 200, Cache: CacheHit, ResponseBytes: 100})
+	p.ObserveRequest(RequestMetrics{Method: "POST", Endpoint: "/organizations", Duration: 2 * time.Second, RequestBytes: 20})
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	want := `# HELP test_requests_total API calls by method, endpoint and final status.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",endpoint="/agents/{id}",status="200"} 2
+test_requests_total{method="POST",endpoint="/organizations",status="error"} 1
+# HELP test_request_duration_seconds API call latency, including retries and rate-limit waits.
+# TYPE test_request_duration_seconds histogram
+test_request_duration_seconds_bucket{method="GET",endpoint="/agents/{id}",le="0.1"} 2
+test_request_duration_seconds_bucket{method="GET",endpoint="/agents/{id}",le="0.5"} 2
+test_request_duration_seconds_bucket{method="GET",endpoint="/agents/{id}",le="+Inf"} 2
+test_request_duration_seconds_sum{method="GET",endpoint="/agents/{id}"} 0.05
+test_request_duration_seconds_count{method="GET",endpoint="/agents/{id}"} 2
+test_request_duration_seconds_bucket{method="POST",endpoint="/organizations",le="0.1"} 0
+test_request_duration_seconds_bucket{method="POST",endpoint="/organizations",le="0.5"} 0
+test_request_duration_seconds_bucket{method="POST",endpoint="/organizations",le="+Inf"} 1
+test_request_duration_seconds_sum{method="POST",endpoint="/organizations"} 2
+test_request_duration_seconds_count{method="POST",endpoint="/organizations"} 1
+# HELP test_request_retries_total Attempts sent after the first.
+# TYPE test_request_retries_total counter
+test_request_retries_total{method="GET",endpoint="/agents/{id}"} 2
+test_request_retries_total{method="POST",endpoint="/organizations"} 0
+# HELP test_cache_requests_total Cached calls by cache outcome.
+# TYPE test_cache_requests_total counter
+test_cache_requests_total{method="GET",endpoint="/agents/{id}",outcome="hit"} 1
+test_cache_requests_total{method="GET",endpoint="/agents/{id}",outcome="miss"} 1
+# HELP test_rate_limit_wait_seconds_total Time spent waiting for the rate limiter.
+# TYPE test_rate_limit_wait_seconds_total counter
+test_rate_limit_wait_seconds_total{method="GET",endpoint="/agents/{id}"} 0.25
+test_rate_limit_wait_seconds_total{method="POST",endpoint="/organizations"} 0
+# HELP test_request_bytes_total Request body bytes sent.
+# TYPE test_request_bytes_total counter
+test_request_bytes_total{method="GET",endpoint="/agents/{id}"} 0
+test_request_bytes_total{method="POST",endpoint="/organizations"} 20
+# HELP test_response_bytes_total Response body bytes received.
+# TYPE test_response_bytes_total counter
+test_response_bytes_total{method="GET",endpoint="/agents/{id}"} 200
+test_response_bytes_total{method="POST",endpoint="/organizations"} 0
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("exposition mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
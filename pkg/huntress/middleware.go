@@ -0,0 +1,154 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+)
+
+// Doer sends an HTTP request and returns its response. *http.Client
+// satisfies Doer, as does every stage of the client's request pipeline.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts an ordinary function to the Doer interface.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer with additional behavior, such as auditing,
+// header injection or request mutation. A middleware must not modify the
+// incoming request in place; clone it first with req.Clone.
+type Middleware func(next Doer) Doer
+
+// pipeline assembles the request pipeline used by Do. Stages run in this
+// order, outermost first:
+//
+//	user middleware (in registration order) → tracing → logging → cache →
+//	coalesce → circuit breaker → retry → rate limit → auth → transport
+//
+// Retries sit outside rate limiting so that every attempt is rate limited.
+// Coalescing sits inside the cache so only cache misses are shared. The
+// circuit breaker sits outside retries so an open circuit is not retried.
+func (c *Client) pipeline() Doer {
+	var d Doer = c.httpClient
+	d = c.attemptStage(d)
+	d = c.authStage(d)
+	d = c.rateLimitStage(d)
+	d = c.retryStage(d)
+	d = c.circuitStage(d)
+	d = c.coalesceStage(d)
+	d = c.cacheStage(d)
+	d = c.loggingStage(d)
+	d = c.tracingStage(d)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		d = c.middleware[i](d)
+	}
+	return d
+}
+
+// authStage adds credentials and the User-Agent to requests that were not
+// built with NewRequest.
+func (c *Client) authStage(next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Authorization") == "" || req.Header.Get("User-Agent") == "" {
+			req = req.Clone(req.Context())
+			if req.Header.Get("Authorization") == "" {
+				req.Header.Set("Authorization", "Basic "+basicAuth(c.apiKey, c.apiSecret))
+			}
+			if req.Header.Get("User-Agent") == "" && c.userAgent != "" {
+				req.Header.Set("User-Agent", c.userAgent)
+			}
+		}
+		return next.Do(req)
+	})
+}
+
+// rateLimitStage waits for the configured RateLimiter before each attempt
+// and reports each response to limiters that implement RateLimitObserver.
+func (c *Client) rateLimitStage(next Doer) Doer {
+	if c.rateLimiter == nil {
+		return next
+	}
+	observer, _ := c.rateLimiter.(RateLimitObserver)
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := withEndpoint(req.Context(), c.endpointPath(req.URL))
+		start := time.Now()
+		err := c.rateLimiter.Wait(ctx)
+		if s := callStatsFromContext(ctx); s != nil {
+			s.rateLimitWait.Add(int64(time.Since(start)))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("rate limit error: %w", err)
+		}
+		resp, err := next.Do(req)
+		if observer != nil && resp != nil {
+			observer.ObserveResponse(ctx, resp)
+		}
+		return resp, err
+	})
+}
+
+// retryStage retries 429/5xx responses and transport errors through the
+// configured Retrier when the request allows it.
+func (c *Client) retryStage(next Doer) Doer {
+	if c.retrier == nil {
+		return next
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if !c.shouldRetry(req) {
+			return next.Do(req)
+		}
+		ctx := req.Context()
+		attempt := 0
+		resp, err := c.retrier.Do(ctx, func() (*http.Response, error) {
+			attemptReq, err := attemptRequest(ctx, req, attempt)
+			if err != nil {
+				return nil, err
+			}
+			attempt++
+			if log := c.requestLogger(req); attempt > 1 && log != nil {
+				log.Debug("Retrying request", logging.String("method", req.Method), logging.String("url", req.URL.String()), logging.Int("attempt", attempt))
+			}
+			return next.Do(attemptReq)
+		})
+		if err != nil {
+			if resp != nil && resp.Body != nil {
+				_ = resp.Body.Close()
+			}
+			return nil, fmt.Errorf("retrier: %w", err)
+		}
+		return resp, nil
+	})
+}
+
+// loggingStage logs outgoing requests and transport failures, and in debug
+// mode the headers and bodies of requests and responses.
+func (c *Client) loggingStage(next Doer) Doer {
+	if c.Logger == nil {
+		return next
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		log := c.requestLogger(req)
+		log.Debug("Sending request", logging.String("method", req.Method), logging.Field{Key: "url", Value: req.URL})
+		if c.debug {
+			logRequestBody(log, req)
+		}
+		resp, err := next.Do(req)
+		if err != nil {
+			log.Error("Request failed", logging.Error("error", err))
+			return resp, err
+		}
+		if c.debug {
+			logResponseBody(log, resp)
+		}
+		return resp, nil
+	})
+}
@@ -0,0 +1,100 @@
+package huntress
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWithMiddleware_OrderAndHeaderInjection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Audit"); got != "first,second" {
+			t.Errorf("expected injected header, got %q", got)
+		}
+		if _, err := w.Write([]byte(`{"id":"acct-1"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				req = req.Clone(req.Context())
+				if prev := req.Header.Get("X-Audit"); prev != "" {
+					name = prev + "," + name
+				}
+				req.Header.Set("X-Audit", name)
+				return next.Do(req)
+			})
+		}
+	}
+
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithMiddleware(tag("first")),
+		WithMiddleware(tag("second")),
+	)
+	account, err := client.Account.Get(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if account.ID != "acct-1" {
+		t.Errorf("expected acct-1, got %q", account.ID)
+	}
+	if !reflect.DeepEqual(order, []string{"first", "second"}) {
+		t.Errorf("unexpected middleware order: %v", order)
+	}
+}
+
+func TestWithMiddleware_ShortCircuit(t *testing.T) {
+	called := false
+	client := New(
+		WithCredentials("k", "s"),
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(func(_ *http.Request) *http.Response {
+			called = true
+			return nil
+		})}),
+		WithMiddleware(func(_ Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Header:     make(http.Header),
+					Body:       http.NoBody,
+					Request:    req,
+				}, nil
+			})
+		}),
+	)
+	_, err := client.Agent.Get(context.Background(), "a1")
+	if !IsNotFoundError(err) {
+		t.Errorf("expected not found error from middleware, got %v", err)
+	}
+	if called {
+		t.Error("transport should not be called when middleware short-circuits")
+	}
+}
+
+func TestAuthStage_AddsMissingCredentials(t *testing.T) {
+	var gotAuth, gotUA string
+	c := &Client{apiKey: "k", apiSecret: "s", userAgent: "ua/1"}
+	d := c.authStage(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		gotAuth, gotUA = req.Header.Get("Authorization"), req.Header.Get("User-Agent")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://example.test/agents", nil)
+	if _, err := d.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Basic "+basicAuth("k", "s") || gotUA != "ua/1" {
+		t.Errorf("unexpected headers: %q %q", gotAuth, gotUA)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("auth stage must not mutate the caller's request")
+	}
+}
@@ -1,9 +1,11 @@
 package huntress
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
 	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
 )
 
@@ -21,8 +23,28 @@ type clientOptions struct {
 	retryConfig *retryConfig
 	debug       bool
 	cacheTTL    time.Duration // TTL for GET response cache
+	// cachePathTTLs overrides cacheTTL for endpoint path prefixes
+	cachePathTTLs map[string]time.Duration
+	// cacheStore replaces the default in-memory cache backend
+	cacheStore CacheStore
+	// staleWhileRevalidate and staleIfError allow serving expired cache entries
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+	// coalesce enables sharing of identical in-flight GET requests
+	coalesce bool
+
+	optimisticConcurrency bool
+	dryRun                bool
+	metrics               Metrics
+	tracer                Tracer
+
+	circuitBreaker *CircuitBreakerConfig
+	// invalidationRules add cache evictions after mutating requests
+	invalidationRules []InvalidationRule
 	// logger is an optional structured logger for the client. If nil, logging is disabled.
 	logger logging.Logger
+	// middleware are caller-supplied stages wrapped around the request pipeline
+	middleware []Middleware
 }
 
 // WithCacheTTL enables GET response caching with the given TTL.
@@ -32,11 +54,142 @@ func WithCacheTTL(ttl time.Duration) Option {
 	}
 }
 
+// WithCachePathTTL overrides the cache TTL for endpoints under pathPrefix,
+// given relative to the base URL (e.g. "/incidents"). The longest matching
+// prefix wins, and a TTL of zero disables caching for those endpoints.
+// It has no effect unless caching is enabled with WithCacheTTL or
+// WithCacheStore.
+func WithCachePathTTL(pathPrefix string, ttl time.Duration) Option {
+	return func(o *clientOptions) {
+		if o.cachePathTTLs == nil {
+			o.cachePathTTLs = make(map[string]time.Duration)
+		}
+		o.cachePathTTLs[pathPrefix] = ttl
+	}
+}
+
+// WithCacheStore sets the backend for GET response caching and enables
+// caching. Responses are kept for the TTL given by WithCacheTTL, or
+// DefaultCacheTTL if none is set.
+func WithCacheStore(store CacheStore) Option {
+	return func(o *clientOptions) {
+		o.cacheStore = store
+	}
+}
+
+// WithStaleWhileRevalidate lets the cache serve an expired response for up
+// to window after it expires while a fresh copy is fetched in the background.
+func WithStaleWhileRevalidate(window time.Duration) Option {
+	return func(o *clientOptions) {
+		o.staleWhileRevalidate = window
+	}
+}
+
+// WithStaleIfError lets the cache serve an expired response for up to window
+// after it expires when the API returns a 5xx status or cannot be reached.
+func WithStaleIfError(window time.Duration) Option {
+	return func(o *clientOptions) {
+		o.staleIfError = window
+	}
+}
+
+// WithCacheInvalidation registers a rule that names extra cache entries to
+// evict after a POST, PUT, PATCH or DELETE, e.g. an organization's agent
+// listings when the organization changes.
+func WithCacheInvalidation(rule InvalidationRule) Option {
+	return func(o *clientOptions) {
+		o.invalidationRules = append(o.invalidationRules, rule)
+	}
+}
+
+// WithRequestCoalescing makes concurrent identical GET requests, keyed by
+// CacheKey, share a single upstream call. Each caller still decodes its own
+// copy of the response. Only the first caller's request is sent, so the API
+// sees only its X-Request-ID; the others log the ID they joined as
+// leader_request_id. See Client.CoalesceStats for the calls saved.
+func WithRequestCoalescing(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.coalesce = enabled
+	}
+}
+
+// WithOptimisticConcurrency makes updates (PATCH and PUT) conditional on the
+// version of the resource the client last read. When the last read returned
+// an ETag the update carries If-Match; otherwise the client compares the
+// resource's updated_at before writing, which costs an extra GET before
+// each such update. Either way a concurrent change makes the update fail
+// with ErrConflict instead of overwriting it, and keeps failing until the
+// resource is read again; see RetryOnConflict. Resources that were never
+// read are updated unconditionally.
+func WithOptimisticConcurrency(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.optimisticConcurrency = enabled
+	}
+}
+
+// WithDryRun makes the client record mutating requests (POST, PATCH, PUT
+// and DELETE) in Client.Plan instead of sending them. Requests are
+// intercepted after they are built and validated, and return a synthetic
+// success response carrying the DryRunHeader; bodies are echoed back where
+// possible so results reflect what was sent. GETs are still sent, so code
+// that reads before it writes can plan against live data. Override it for
+// a single call with the DryRun request option.
+func WithDryRun(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.dryRun = enabled
+	}
+}
+
+// WithMetrics reports every API call to m: its endpoint template, status,
+// latency, retries, cache outcome, rate-limit wait and bytes transferred.
+// NewPrometheusCollector provides a Metrics that serves the measurements in
+// the Prometheus text format.
+func WithMetrics(m Metrics) Option {
+	return func(o *clientOptions) {
+		o.metrics = m
+	}
+}
+
+// WithTracer starts a span with t for every API call, including calls
+// answered from the cache, and sends the API a W3C traceparent header
+// linking its work to the span. Spans carry the method, endpoint template,
+// final status, number of attempts and cache outcome. Use
+// NewInMemoryTracer in tests, or adapt another tracing backend to Tracer.
+func WithTracer(t Tracer) Option {
+	return func(o *clientOptions) {
+		o.tracer = t
+	}
+}
+
+// WithCircuitBreaker fails requests fast with ErrCircuitOpen while an
+// endpoint group's rate of 5xx responses and timeouts is above
+// cfg.FailureRate. State changes are logged through the client's Logger and
+// passed to cfg.OnStateChange.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) Option {
+	return func(o *clientOptions) {
+		o.circuitBreaker = &cfg
+	}
+}
+
 // retryConfig defines retry behavior for API requests
 type retryConfig struct {
 	MaxRetries   int
 	RetryWaitMin time.Duration
 	RetryWaitMax time.Duration
+	// MaxElapsed caps the total time spent across all attempts (0 = no cap)
+	MaxElapsed time.Duration
+	// RetryNonIdempotent allows POST and PATCH requests to be retried
+	RetryNonIdempotent bool
+}
+
+// defaultRetryConfig returns the retry settings used when retries are enabled
+// without an explicit WithRetryConfig.
+func defaultRetryConfig() *retryConfig {
+	return &retryConfig{
+		MaxRetries:   retry.DefaultConfig.MaxRetries,
+		RetryWaitMin: retry.DefaultConfig.BaseDelay,
+		RetryWaitMax: retry.DefaultConfig.MaxDelay,
+	}
 }
 
 // Option is a function that configures client options
@@ -88,18 +241,53 @@ func WithRateLimiter(limiter RateLimiter) Option {
 	}
 }
 
-// WithRetryConfig configures the retry behavior for the client
+// WithRetryConfig configures the retry behavior for the client.
+// Requests failing with 429, 5xx or a transport error are retried up to
+// maxRetries times using jittered exponential backoff between minWait and
+// maxWait, unless the server supplies a Retry-After or X-RateLimit-Reset hint.
+// A hint longer than maxWait is not waited out: the call fails at once with a
+// RateLimitError or APIError carrying it.
 func WithRetryConfig(maxRetries int, minWait, maxWait time.Duration) Option {
 	return func(o *clientOptions) {
-		o.retryConfig = &retryConfig{
-			MaxRetries:   maxRetries,
-			RetryWaitMin: minWait,
-			RetryWaitMax: maxWait,
+		if o.retryConfig == nil {
+			o.retryConfig = &retryConfig{}
+		}
+		o.retryConfig.MaxRetries = maxRetries
+		o.retryConfig.RetryWaitMin = minWait
+		o.retryConfig.RetryWaitMax = maxWait
+	}
+}
+
+// WithMaxRetryDuration caps the total time a single call may spend across all
+// retry attempts, including backoff delays. It enables retries with default
+// settings if WithRetryConfig was not given.
+func WithMaxRetryDuration(d time.Duration) Option {
+	return func(o *clientOptions) {
+		if o.retryConfig == nil {
+			o.retryConfig = defaultRetryConfig()
+		}
+		o.retryConfig.MaxElapsed = d
+	}
+}
+
+// WithRetryNonIdempotent allows non-idempotent requests such as PATCH to be
+// retried. By default only GET, HEAD, OPTIONS, PUT and DELETE are retried,
+// along with POST requests, which carry an Idempotency-Key; see
+// IdempotencyKey.
+func WithRetryNonIdempotent(enabled bool) Option {
+	return func(o *clientOptions) {
+		if o.retryConfig == nil {
+			o.retryConfig = defaultRetryConfig()
 		}
+		o.retryConfig.RetryNonIdempotent = enabled
 	}
 }
 
-// WithDebug enables debug logging
+// WithDebug logs the headers and bodies of requests and responses at debug
+// level, through the same redaction as all other output: the Authorization
+// header, credential-like fields such as WebhookPassword, the API secret and
+// email addresses never appear. Bodies that are not JSON are logged as
+// their size. Without WithLogger or WithSlogHandler, output goes to stderr.
 func WithDebug(debug bool) Option {
 	return func(o *clientOptions) {
 		o.debug = debug
@@ -114,8 +302,27 @@ func WithAPIVersion(version string) Option {
 }
 
 // WithLogger sets a structured logger for the Huntress client. If not set, logging is disabled.
+// Everything the client logs is redacted first; see WithDebug.
 func WithLogger(logger logging.Logger) Option {
 	return func(o *clientOptions) {
 		o.logger = logger
 	}
 }
+
+// WithSlogHandler logs through a log/slog handler, wrapped with
+// NewRedactingHandler. It is shorthand for WithLogger(NewSlogLogger(h)).
+func WithSlogHandler(h slog.Handler) Option {
+	return func(o *clientOptions) {
+		o.logger = NewSlogLogger(h)
+	}
+}
+
+// WithMiddleware adds stages to the client's request pipeline. Middleware wrap
+// the built-in stages (logging, caching, retries, rate limiting and auth) and
+// run in the order given, so the first middleware sees the request first.
+// The option may be repeated; later calls append to the chain.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o *clientOptions) {
+		o.middleware = append(o.middleware, mw...)
+	}
+}
@@ -4,6 +4,7 @@ package huntress
 import (
 	"context"
 	"fmt"
+	"iter"
 	"net/http"
 )
 
@@ -45,6 +46,32 @@ func (s *organizationService) List(ctx context.Context, params *ListOrganization
 	return orgs, pagination, nil
 }
 
+// All iterates over every organization matching params, fetching pages lazily
+func (s *organizationService) All(ctx context.Context, params *ListOrganizationsParams) iter.Seq2[*Organization, error] {
+	var opts ListOrganizationsParams
+	if params != nil {
+		opts = *params
+	}
+	return paginate(ctx, opts.Page, opts.PerPage, func(ctx context.Context, page int) ([]*Organization, *Pagination, error) {
+		opts.Page = page
+		return s.List(ctx, &opts)
+	}, func(o *Organization) string { return o.ID })
+}
+
+// AllParallel iterates over every organization matching params, fetching pages
+// concurrently once the first response reports the total page count
+func (s *organizationService) AllParallel(ctx context.Context, params *ListOrganizationsParams, opts *PrefetchOptions) iter.Seq2[*Organization, error] {
+	var base ListOrganizationsParams
+	if params != nil {
+		base = *params
+	}
+	return prefetch(ctx, base.Page, base.PerPage, func(ctx context.Context, page int) ([]*Organization, *Pagination, error) {
+		p := base
+		p.Page = page
+		return s.List(ctx, &p)
+	}, func(o *Organization) string { return o.ID }, opts)
+}
+
 // Create creates a new organization
 func (s *organizationService) Create(ctx context.Context, org *OrganizationCreateParams) (*Organization, error) {
 	if err := org.Validate(); err != nil {
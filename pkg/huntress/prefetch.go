@@ -0,0 +1,179 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+)
+
+// defaultPrefetchConcurrency is the number of pages fetched at once when
+// PrefetchOptions does not say otherwise.
+const defaultPrefetchConcurrency = 4
+
+// PrefetchOptions controls how AllParallel fetches pages.
+type PrefetchOptions struct {
+	// Concurrency is the maximum number of page requests in flight.
+	// Defaults to 4. Every request still passes through the client's
+	// RateLimiter, so a higher value never exceeds the configured rate.
+	Concurrency int
+	// Unordered yields each page as soon as it arrives instead of in page
+	// order. This keeps memory flat when a single slow page would otherwise
+	// hold back the ones after it.
+	Unordered bool
+}
+
+// pageResult carries one fetched page from a worker to the consumer.
+type pageResult[T any] struct {
+	page  int
+	items []*T
+	err   error
+}
+
+// prefetch returns an iterator that fetches startPage first, reads the page
+// count from its X-Total-Pages header and then fetches the remaining pages
+// with at most opts.Concurrency requests in flight. When the first response
+// carries no page count it falls back to serial pagination. fetch must be
+// safe to call from several goroutines.
+func prefetch[T any](ctx context.Context, startPage, perPage int, fetch pageFetcher[T], id func(*T) string, opts *PrefetchOptions) iter.Seq2[*T, error] {
+	workers, unordered := defaultPrefetchConcurrency, false
+	if opts != nil {
+		if opts.Concurrency > 0 {
+			workers = opts.Concurrency
+		}
+		unordered = opts.Unordered
+	}
+	return func(yield func(*T, error) bool) {
+		if startPage < 1 {
+			startPage = 1
+		}
+		items, pagination, err := fetch(ctx, startPage)
+		if err != nil {
+			yield(nil, fmt.Errorf("fetching page %d: %w", startPage, err))
+			return
+		}
+		if pagination == nil || pagination.TotalPages == 0 {
+			first := func(ctx context.Context, page int) ([]*T, *Pagination, error) {
+				if page == startPage {
+					return items, pagination, nil
+				}
+				return fetch(ctx, page)
+			}
+			for item, err := range paginate(ctx, startPage, perPage, first, id) {
+				if !yield(item, err) {
+					return
+				}
+			}
+			return
+		}
+
+		seen := make(map[string]struct{})
+		emit := func(items []*T) bool {
+			for _, item := range items {
+				if item == nil {
+					continue
+				}
+				if key := id(item); key != "" {
+					if _, dup := seen[key]; dup {
+						continue
+					}
+					seen[key] = struct{}{}
+				}
+				if !yield(item, nil) {
+					return false
+				}
+			}
+			return true
+		}
+		if !emit(items) || startPage >= pagination.TotalPages {
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		for r := range fetchPages(ctx, startPage+1, pagination.TotalPages, workers, unordered, fetch) {
+			if r.err != nil {
+				yield(nil, fmt.Errorf("fetching page %d: %w", r.page, r.err))
+				return
+			}
+			if !emit(r.items) {
+				return
+			}
+		}
+	}
+}
+
+// fetchPages fetches pages first through last with at most workers requests
+// in flight and returns their results on a channel that is closed once every
+// page has been delivered or ctx is canceled. In ordered mode results arrive
+// in page order and at most 2*workers pages are buffered ahead of the
+// consumer; otherwise they arrive as they complete.
+func fetchPages[T any](ctx context.Context, first, last, workers int, unordered bool, fetch pageFetcher[T]) <-chan pageResult[T] {
+	out := make(chan pageResult[T], workers)
+	slots := make(chan chan pageResult[T], workers)
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	go func() {
+		defer func() {
+			close(slots)
+			if unordered {
+				wg.Wait()
+				close(out)
+			}
+		}()
+		for page := first; page <= last; page++ {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			var slot chan pageResult[T]
+			if !unordered {
+				slot = make(chan pageResult[T], 1)
+				select {
+				case slots <- slot:
+				case <-ctx.Done():
+					<-sem
+					return
+				}
+			}
+			wg.Add(1)
+			go func(page int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				items, _, err := fetch(ctx, page)
+				r := pageResult[T]{page: page, items: items, err: err}
+				if slot != nil {
+					slot <- r
+					return
+				}
+				select {
+				case out <- r:
+				case <-ctx.Done():
+				}
+			}(page)
+		}
+	}()
+
+	if !unordered {
+		go func() {
+			defer close(out)
+			for slot := range slots {
+				var r pageResult[T]
+				select {
+				case r = <-slot:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingLimiter struct {
+	waits int32
+}
+
+func (l *countingLimiter) Wait(_ context.Context) error {
+	atomic.AddInt32(&l.waits, 1)
+	return nil
+}
+
+func (l *countingLimiter) Reserve() (bool, time.Duration) { return true, 0 }
+
+func pagedIDs(pages, perPage int) [][]string {
+	out := make([][]string, pages)
+	for p := range out {
+		for i := 0; i < perPage; i++ {
+			out[p] = append(out[p], fmt.Sprintf("a%03d", p*perPage+i))
+		}
+	}
+	return out
+}
+
+func TestAgentService_AllParallel_Ordered(t *testing.T) {
+	pages := pagedIDs(9, 3)
+	srv, calls := newPagedServer(t, pages)
+	limiter := &countingLimiter{}
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithRateLimiter(limiter))
+
+	agents, err := Collect(client.Agent.AllParallel(context.Background(), nil, &PrefetchOptions{Concurrency: 3}), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var want []string
+	for _, p := range pages {
+		want = append(want, p...)
+	}
+	if got := fmt.Sprint(agentIDs(agents)); got != fmt.Sprint(want) {
+		t.Errorf("unexpected order:\n got %s\nwant %v", got, want)
+	}
+	if got := atomic.LoadInt32(calls); got != 9 {
+		t.Errorf("expected 9 requests, got %d", got)
+	}
+	if got := atomic.LoadInt32(&limiter.waits); got != 9 {
+		t.Errorf("expected the rate limiter to be consulted 9 times, got %d", got)
+	}
+}
+
+func TestAgentService_AllParallel_Unordered(t *testing.T) {
+	srv, _ := newPagedServer(t, pagedIDs(6, 2))
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+
+	agents, err := Collect(client.Agent.AllParallel(context.Background(), nil, &PrefetchOptions{Unordered: true}), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := agentIDs(agents)
+	sort.Strings(ids)
+	if len(ids) != 12 || ids[0] != "a000" || ids[11] != "a011" {
+		t.Errorf("unexpected agents: %v", ids)
+	}
+}
+
+func TestPrefetch_BoundsConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	fetch := func(_ context.Context, page int) ([]*Agent, *Pagination, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return []*Agent{{ID: strconv.Itoa(page)}}, &Pagination{TotalPages: 20}, nil
+	}
+	items, err := Collect(prefetch(context.Background(), 1, 1, fetch, func(a *Agent) string { return a.ID }, &PrefetchOptions{Concurrency: 2}), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 20 {
+		t.Errorf("expected 20 items, got %d", len(items))
+	}
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Errorf("expected at most 2 requests in flight, saw %d", got)
+	}
+}
+
+func TestPrefetch_StopsOnError(t *testing.T) {
+	boom := errors.New("boom")
+	fetch := func(_ context.Context, page int) ([]*Agent, *Pagination, error) {
+		if page == 3 {
+			return nil, nil, boom
+		}
+		return []*Agent{{ID: strconv.Itoa(page)}}, &Pagination{TotalPages: 10}, nil
+	}
+	items, err := Collect(prefetch(context.Background(), 1, 1, fetch, func(a *Agent) string { return a.ID }, nil), 0)
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected wrapped fetch error, got %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected pages before the failure to be yielded, got %d items", len(items))
+	}
+}
+
+func TestPrefetch_FallsBackWithoutTotalPages(t *testing.T) {
+	fetch := func(_ context.Context, page int) ([]*Agent, *Pagination, error) {
+		if page > 3 {
+			return nil, &Pagination{}, nil
+		}
+		return []*Agent{{ID: strconv.Itoa(page)}}, &Pagination{}, nil
+	}
+	items, err := Collect(prefetch(context.Background(), 1, 1, fetch, func(a *Agent) string { return a.ID }, nil), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(agentIDs(items)); got != "[1 2 3]" {
+		t.Errorf("unexpected agents: %s", got)
+	}
+}
+
+func TestPrefetch_EarlyBreakReleasesWorkers(t *testing.T) {
+	fetch := func(ctx context.Context, page int) ([]*Agent, *Pagination, error) {
+		if page > 2 {
+			<-ctx.Done()
+		}
+		return []*Agent{{ID: strconv.Itoa(page)}}, &Pagination{TotalPages: 50}, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for a := range prefetch(context.Background(), 1, 1, fetch, func(a *Agent) string { return a.ID }, nil) {
+			if a.ID == "2" {
+				break
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("iterator did not return after break")
+	}
+}
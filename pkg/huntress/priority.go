@@ -0,0 +1,55 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import "context"
+
+// Priority orders requests waiting on a rate limiter that supports it, such
+// as TokenBucketLimiter. Higher priorities are served first.
+type Priority int
+
+// Request priorities. The zero value is PriorityNormal.
+const (
+	// PriorityBulk is for background work such as syncs and exports.
+	PriorityBulk Priority = -1
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+	// PriorityHigh is for interactive work that a person is waiting on.
+	PriorityHigh Priority = 1
+)
+
+// String returns the priority's name.
+func (p Priority) String() string {
+	switch {
+	case p >= PriorityHigh:
+		return "high"
+	case p <= PriorityBulk:
+		return "bulk"
+	}
+	return "normal"
+}
+
+// lane maps a priority to a queue index, highest first.
+func (p Priority) lane() int {
+	switch {
+	case p >= PriorityHigh:
+		return 0
+	case p <= PriorityBulk:
+		return 2
+	}
+	return 1
+}
+
+type priorityCtxKey struct{}
+
+// WithPriority returns a context that makes requests sent with it wait at
+// priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, p)
+}
+
+// PriorityFromContext returns the priority set with WithPriority, or
+// PriorityNormal.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityCtxKey{}).(Priority)
+	return p
+}
@@ -0,0 +1,406 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
+)
+
+// Huntress API limits: 60 requests per minute per API key.
+const (
+	DefaultRateLimit       = 60
+	DefaultRateLimitPeriod = time.Minute
+)
+
+// RateLimitObserver is implemented by rate limiters that adjust themselves
+// from API responses. The client calls ObserveResponse after every attempt
+// with the context that was passed to Wait.
+type RateLimitObserver interface {
+	ObserveResponse(ctx context.Context, resp *http.Response)
+}
+
+// endpointCtxKey carries the endpoint path of the request being rate limited.
+type endpointCtxKey struct{}
+
+func withEndpoint(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, endpointCtxKey{}, path)
+}
+
+func endpointFromContext(ctx context.Context) string {
+	path, _ := ctx.Value(endpointCtxKey{}).(string)
+	return path
+}
+
+// Budget is a token bucket allowance of Limit requests per Period with up
+// to Burst requests sent back to back.
+type Budget struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// TokenBucketConfig configures a TokenBucketLimiter.
+type TokenBucketConfig struct {
+	// Budget is the overall allowance for the API key. Zero fields default
+	// to 60 requests per minute with a burst of 10.
+	Budget
+	// Groups gives endpoint groups, keyed by path prefix relative to the
+	// base URL (e.g. "/reports"), their own budget on top of the overall
+	// one. The longest matching prefix wins.
+	Groups map[string]Budget
+	// BulkShare caps PriorityBulk requests at this fraction (0-1] of the
+	// overall budget so bulk work cannot starve interactive callers.
+	// Zero means bulk requests are only limited by priority ordering.
+	BulkShare float64
+}
+
+// TokenBucketLimiter is a RateLimiter that refills tokens continuously. It
+// follows X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// response headers and, after a 429, waits out Retry-After and then refills
+// at half speed for one period.
+//
+// Waiting requests are served in priority order (see WithPriority) and in
+// arrival order within a priority.
+type TokenBucketLimiter struct {
+	mu           sync.Mutex
+	global       *bucket
+	bulk         *bucket
+	groups       map[string]*bucket
+	period       time.Duration
+	blockedUntil time.Time
+	resetAt      time.Time
+	slowUntil    time.Time
+	lanes        [3][]*waiter
+	timer        *time.Timer
+	now          func() time.Time
+}
+
+// waiter is a queued Wait call.
+type waiter struct {
+	lane    int
+	group   *bucket
+	ready   chan struct{}
+	granted bool
+}
+
+// NewTokenBucketLimiter creates a TokenBucketLimiter from cfg.
+func NewTokenBucketLimiter(cfg TokenBucketConfig) *TokenBucketLimiter {
+	global := cfg.Budget.withDefaults(DefaultRateLimit, DefaultRateLimitPeriod, 10)
+	l := &TokenBucketLimiter{
+		global: newBucket(global),
+		groups: make(map[string]*bucket, len(cfg.Groups)),
+		period: global.Period,
+		now:    time.Now,
+	}
+	for prefix, b := range cfg.Groups {
+		l.groups[normalizeCachePath(prefix)] = newBucket(b.withDefaults(global.Limit, global.Period, 1))
+	}
+	if cfg.BulkShare > 0 && cfg.BulkShare < 1 {
+		l.bulk = newBucket(Budget{
+			Limit:  max(1, int(float64(global.Limit)*cfg.BulkShare)),
+			Period: global.Period,
+			Burst:  max(1, int(float64(global.Burst)*cfg.BulkShare)),
+		})
+	}
+	return l
+}
+
+func (b Budget) withDefaults(limit int, period time.Duration, burst int) Budget {
+	if b.Limit <= 0 {
+		b.Limit = limit
+	}
+	if b.Period <= 0 {
+		b.Period = period
+	}
+	if b.Burst <= 0 {
+		b.Burst = min(burst, b.Limit)
+	}
+	return b
+}
+
+// Wait blocks until the request may be sent or ctx is done. The request
+// needs a token from the overall budget, from its endpoint group's budget
+// and, for PriorityBulk, from the bulk share.
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	w := &waiter{
+		lane:  PriorityFromContext(ctx).lane(),
+		group: l.group(endpointFromContext(ctx)),
+		ready: make(chan struct{}),
+	}
+	l.mu.Lock()
+	l.lanes[w.lane] = append(l.lanes[w.lane], w)
+	l.dispatch()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if w.granted {
+			l.refund(w)
+		} else {
+			l.dequeue(w)
+		}
+		l.mu.Unlock()
+		return fmt.Errorf("rate limiter: context error: %w", ctx.Err())
+	}
+}
+
+// Reserve takes a token from the overall budget if one is available now and
+// nobody is queued. Otherwise it takes nothing and reports roughly how long
+// until one will be.
+func (l *TokenBucketLimiter) Reserve() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.refill(now)
+	if now.Before(l.blockedUntil) {
+		return false, l.blockedUntil.Sub(now)
+	}
+	if l.global.tokens >= 1 && l.queued() == 0 {
+		l.global.tokens--
+		return true, 0
+	}
+	return false, l.global.delay(float64(l.queued())+1-l.global.tokens, l.factor(now))
+}
+
+// ObserveResponse corrects the limiter from the rate limit headers on resp.
+func (l *TokenBucketLimiter) ObserveResponse(_ context.Context, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.refill(now)
+
+	if limit, ok := headerInt(resp.Header, "X-RateLimit-Limit"); ok && limit > 0 {
+		l.global.setRate(limit, l.period)
+	}
+	if remaining, ok := headerInt(resp.Header, "X-RateLimit-Remaining"); ok && float64(remaining) < l.global.tokens {
+		l.global.tokens = float64(remaining)
+	}
+	if wait, ok := retry.RetryAfter(resp, now); ok && wait > 0 {
+		until := now.Add(wait)
+		if until.After(l.blockedUntil) {
+			l.blockedUntil = until
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			// The server's window resets then; start again with a full burst
+			l.resetAt = until
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		l.global.tokens = min(l.global.tokens, 0)
+		if l.blockedUntil.Before(now) {
+			l.blockedUntil = now.Add(l.global.interval())
+		}
+		l.slowUntil = l.blockedUntil.Add(l.period)
+	}
+	l.dispatch()
+}
+
+// dispatch grants tokens to queued waiters in priority order and arms a
+// timer for the next time one could be granted. The caller must hold l.mu.
+func (l *TokenBucketLimiter) dispatch() {
+	now := l.now()
+	l.refill(now)
+	for {
+		w := l.nextEligible(now)
+		if w == nil {
+			break
+		}
+		l.dequeue(w)
+		l.global.tokens--
+		if w.group != nil {
+			w.group.tokens--
+		}
+		if w.lane == PriorityBulk.lane() && l.bulk != nil {
+			l.bulk.tokens--
+		}
+		w.granted = true
+		close(w.ready)
+	}
+	l.schedule(now)
+}
+
+// nextEligible returns the first waiter, by priority then arrival, whose
+// buckets all hold a token. The caller must hold l.mu.
+func (l *TokenBucketLimiter) nextEligible(now time.Time) *waiter {
+	if now.Before(l.blockedUntil) || l.global.tokens < 1 {
+		return nil
+	}
+	for lane, queue := range l.lanes {
+		if lane == PriorityBulk.lane() && l.bulk != nil && l.bulk.tokens < 1 {
+			continue
+		}
+		for _, w := range queue {
+			if w.group == nil || w.group.tokens >= 1 {
+				return w
+			}
+		}
+	}
+	return nil
+}
+
+// schedule arms the dispatch timer for the earliest time a queued waiter
+// could be granted a token. The caller must hold l.mu.
+func (l *TokenBucketLimiter) schedule(now time.Time) {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if l.queued() == 0 {
+		return
+	}
+	factor := l.factor(now)
+	next := time.Duration(-1)
+	for lane, queue := range l.lanes {
+		for _, w := range queue {
+			d := l.global.delay(1-l.global.tokens, factor)
+			if w.group != nil {
+				d = max(d, w.group.delay(1-w.group.tokens, 1))
+			}
+			if lane == PriorityBulk.lane() && l.bulk != nil {
+				d = max(d, l.bulk.delay(1-l.bulk.tokens, 1))
+			}
+			if next < 0 || d < next {
+				next = d
+			}
+		}
+	}
+	next = max(next, l.blockedUntil.Sub(now), time.Millisecond)
+	l.timer = time.AfterFunc(next, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.dispatch()
+	})
+}
+
+// dequeue removes w from its lane. The caller must hold l.mu.
+func (l *TokenBucketLimiter) dequeue(w *waiter) {
+	queue := l.lanes[w.lane]
+	for i, q := range queue {
+		if q == w {
+			l.lanes[w.lane] = append(queue[:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// refund returns the tokens of a granted waiter whose caller gave up.
+// The caller must hold l.mu.
+func (l *TokenBucketLimiter) refund(w *waiter) {
+	l.global.tokens = min(l.global.burst, l.global.tokens+1)
+	if w.group != nil {
+		w.group.tokens = min(w.group.burst, w.group.tokens+1)
+	}
+	if w.lane == PriorityBulk.lane() && l.bulk != nil {
+		l.bulk.tokens = min(l.bulk.burst, l.bulk.tokens+1)
+	}
+	l.dispatch()
+}
+
+func (l *TokenBucketLimiter) queued() int {
+	n := 0
+	for _, queue := range l.lanes {
+		n += len(queue)
+	}
+	return n
+}
+
+// refill tops up every bucket. The overall bucket earns nothing while
+// blocked and is refilled completely when the server's window resets.
+func (l *TokenBucketLimiter) refill(now time.Time) {
+	if !l.resetAt.IsZero() && !now.Before(l.resetAt) {
+		l.global.tokens = l.global.burst
+		l.global.last = l.resetAt
+		l.resetAt = time.Time{}
+	}
+	if l.global.last.Before(l.blockedUntil) {
+		if now.Before(l.blockedUntil) {
+			l.global.last = now
+		} else {
+			l.global.last = l.blockedUntil
+		}
+	}
+	l.global.refill(now, l.factor(now))
+	if l.bulk != nil {
+		l.bulk.refill(now, 1)
+	}
+	for _, b := range l.groups {
+		b.refill(now, 1)
+	}
+}
+
+// factor is the refill speed multiplier: halved while recovering from a 429.
+func (l *TokenBucketLimiter) factor(now time.Time) float64 {
+	if now.Before(l.slowUntil) {
+		return 0.5
+	}
+	return 1
+}
+
+// group returns the bucket for the longest configured prefix of path.
+func (l *TokenBucketLimiter) group(path string) *bucket {
+	var found *bucket
+	longest := -1
+	for prefix, b := range l.groups {
+		if len(prefix) > longest && pathHasPrefix(path, prefix) {
+			found, longest = b, len(prefix)
+		}
+	}
+	return found
+}
+
+// bucket is a token bucket.
+type bucket struct {
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(b Budget) *bucket {
+	bk := &bucket{burst: float64(b.Burst), tokens: float64(b.Burst)}
+	bk.setRate(b.Limit, b.Period)
+	return bk
+}
+
+func (b *bucket) setRate(limit int, period time.Duration) {
+	b.rate = float64(limit) / period.Seconds()
+	b.burst = min(b.burst, float64(limit))
+}
+
+func (b *bucket) refill(now time.Time, factor float64) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate*factor)
+	}
+	b.last = now
+}
+
+// delay is how long it takes to earn tokens at the given speed factor.
+func (b *bucket) delay(tokens, factor float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / (b.rate * factor) * float64(time.Second))
+}
+
+// interval is the time to earn one token at the normal rate.
+func (b *bucket) interval() time.Duration {
+	return time.Duration(float64(time.Second) / b.rate)
+}
+
+func headerInt(h http.Header, name string) (int, bool) {
+	v, err := strconv.Atoi(strings.TrimSpace(h.Get(name)))
+	return v, err == nil
+}
@@ -0,0 +1,243 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/filelock"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
+)
+
+// SharedRateLimiterConfig configures a SharedRateLimiter.
+type SharedRateLimiterConfig struct {
+	// Path is the state file shared by every cooperating process. When
+	// empty, a file derived from Name is used under $XDG_RUNTIME_DIR, or
+	// the system temporary directory if that is unset.
+	Path string
+	// Name identifies the budget when Path is empty. Processes using the
+	// same API key should use the same Name; the API key ID is a good
+	// choice. It is hashed before use in a file name.
+	Name string
+	// Limit requests are allowed per Window. Defaults to 60 per minute.
+	Limit  int
+	Window time.Duration
+}
+
+// SharedRateLimiter is a RateLimiter whose budget is shared by every process
+// on the host that uses the same state file. Each Wait books a send time
+// in the file under an exclusive lock, so processes queue fairly behind one
+// another. Future bookings left by processes that have exited are discarded,
+// and a 429 seen by any process pauses all of them.
+type SharedRateLimiter struct {
+	path   string
+	limit  int
+	window time.Duration
+	pid    int
+	now    func() time.Time
+}
+
+// sharedState is the JSON content of the state file.
+type sharedState struct {
+	Slots        []sharedSlot `json:"slots"`
+	BlockedUntil time.Time    `json:"blocked_until,omitempty"`
+}
+
+// sharedSlot is one booked request.
+type sharedSlot struct {
+	PID int       `json:"pid"`
+	At  time.Time `json:"at"`
+}
+
+// NewSharedRateLimiter creates a SharedRateLimiter, creating the state
+// file's directory if needed.
+func NewSharedRateLimiter(cfg SharedRateLimiterConfig) (*SharedRateLimiter, error) {
+	path := cfg.Path
+	if path == "" {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		sum := sha256.Sum256([]byte(cfg.Name))
+		path = filepath.Join(dir, "bishoujo-huntress", "ratelimit-"+hex.EncodeToString(sum[:8])+".json")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating rate limit state directory: %w", err)
+	}
+	l := &SharedRateLimiter{
+		path:   path,
+		limit:  cfg.Limit,
+		window: cfg.Window,
+		pid:    os.Getpid(),
+		now:    time.Now,
+	}
+	if l.limit <= 0 {
+		l.limit = DefaultRateLimit
+	}
+	if l.window <= 0 {
+		l.window = DefaultRateLimitPeriod
+	}
+	return l, nil
+}
+
+// Path returns the state file location.
+func (l *SharedRateLimiter) Path() string {
+	return l.path
+}
+
+// Wait books the next free send time and sleeps until it arrives. If ctx
+// ends first the booking is released for other processes.
+func (l *SharedRateLimiter) Wait(ctx context.Context) error {
+	var at time.Time
+	err := l.update(ctx, func(s *sharedState, now time.Time) {
+		at = l.nextSlot(s, now)
+		s.Slots = append(s.Slots, sharedSlot{PID: l.pid, At: at})
+	})
+	if err != nil {
+		return err
+	}
+	wait := at.Sub(l.now())
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		_ = l.update(context.WithoutCancel(ctx), func(s *sharedState, _ time.Time) {
+			for i, slot := range s.Slots {
+				if slot.PID == l.pid && slot.At.Equal(at) {
+					s.Slots = append(s.Slots[:i], s.Slots[i+1:]...)
+					break
+				}
+			}
+		})
+		return fmt.Errorf("rate limiter: context error: %w", ctx.Err())
+	}
+}
+
+// Reserve books a slot if one is free now. Otherwise it books nothing and
+// reports how long until the next free slot.
+func (l *SharedRateLimiter) Reserve() (bool, time.Duration) {
+	var ok bool
+	var wait time.Duration
+	err := l.update(context.Background(), func(s *sharedState, now time.Time) {
+		at := l.nextSlot(s, now)
+		if at.After(now) {
+			wait = at.Sub(now)
+			return
+		}
+		ok = true
+		s.Slots = append(s.Slots, sharedSlot{PID: l.pid, At: now})
+	})
+	if err != nil {
+		return false, l.window
+	}
+	return ok, wait
+}
+
+// ObserveResponse pauses every process sharing the budget when the API
+// reports that the budget is exhausted: for as long as a Retry-After or
+// X-RateLimit-Reset header asks, or for one slot interval (Window / Limit)
+// on a 429 that gives no hint.
+func (l *SharedRateLimiter) ObserveResponse(ctx context.Context, resp *http.Response) {
+	now := l.now()
+	wait, ok := retry.RetryAfter(resp, now)
+	if !ok && resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		wait = l.window / time.Duration(l.limit)
+	}
+	if wait <= 0 {
+		return
+	}
+	until := now.Add(wait)
+	_ = l.update(context.WithoutCancel(ctx), func(s *sharedState, _ time.Time) {
+		if until.After(s.BlockedUntil) {
+			s.BlockedUntil = until
+		}
+	})
+}
+
+// nextSlot returns the earliest time a new request fits in the sliding
+// window after every existing booking.
+func (l *SharedRateLimiter) nextSlot(s *sharedState, now time.Time) time.Time {
+	at := now
+	if s.BlockedUntil.After(at) {
+		at = s.BlockedUntil
+	}
+	if n := len(s.Slots); n >= l.limit {
+		if free := s.Slots[n-l.limit].At.Add(l.window); free.After(at) {
+			at = free
+		}
+	}
+	return at
+}
+
+// update runs fn on the state file's content under an exclusive lock and
+// writes the result back. Unreadable state is treated as empty.
+func (l *SharedRateLimiter) update(ctx context.Context, fn func(s *sharedState, now time.Time)) error {
+	unlock, err := filelock.Lock(ctx, l.path)
+	if err != nil {
+		return fmt.Errorf("rate limiter: %w", err)
+	}
+	defer func() { _ = unlock() }()
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o600) // #nosec G304 -- path is set by the caller or derived from a hash
+	if err != nil {
+		return fmt.Errorf("rate limiter: opening state: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var s sharedState
+	if data, err := io.ReadAll(f); err == nil && len(data) > 0 {
+		if json.Unmarshal(data, &s) != nil {
+			s = sharedState{}
+		}
+	}
+	now := l.now()
+	l.prune(&s, now)
+	fn(&s, now)
+	sort.Slice(s.Slots, func(i, j int) bool { return s.Slots[i].At.Before(s.Slots[j].At) })
+
+	data, err := json.Marshal(&s)
+	if err != nil {
+		return fmt.Errorf("rate limiter: encoding state: %w", err)
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("rate limiter: writing state: %w", err)
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("rate limiter: writing state: %w", err)
+	}
+	return nil
+}
+
+// prune drops bookings that have left the window and future bookings held
+// by processes that no longer exist.
+func (l *SharedRateLimiter) prune(s *sharedState, now time.Time) {
+	cutoff := now.Add(-l.window)
+	kept := s.Slots[:0]
+	for _, slot := range s.Slots {
+		if !slot.At.After(cutoff) {
+			continue
+		}
+		if slot.At.After(now) && slot.PID != l.pid && !filelock.ProcessAlive(slot.PID) {
+			continue
+		}
+		kept = append(kept, slot)
+	}
+	s.Slots = kept
+	if !s.BlockedUntil.After(now) {
+		s.BlockedUntil = time.Time{}
+	}
+}
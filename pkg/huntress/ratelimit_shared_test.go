@@ -0,0 +1,155 @@
+package huntress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestSharedLimiter(t *testing.T, path string, pid int, clock *fakeClock) *SharedRateLimiter {
+	t.Helper()
+	l, err := NewSharedRateLimiter(SharedRateLimiterConfig{Path: path, Limit: 2, Window: time.Minute})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l.pid = pid
+	l.now = clock.now
+	return l
+}
+
+func readSharedState(t *testing.T, path string) sharedState {
+	t.Helper()
+	data, err := os.ReadFile(path) // #nosec G304 -- test file in a temp dir
+	if err != nil {
+		t.Fatalf("error reading state: %v", err)
+	}
+	var s sharedState
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("error decoding state: %v", err)
+	}
+	return s
+}
+
+func TestSharedRateLimiter_SharesBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	a := newTestSharedLimiter(t, path, os.Getpid(), clock)
+	b := newTestSharedLimiter(t, path, os.Getpid(), clock)
+
+	if ok, _ := a.Reserve(); !ok {
+		t.Fatal("expected the first slot to be free")
+	}
+	if ok, _ := b.Reserve(); !ok {
+		t.Fatal("expected the second slot to be free")
+	}
+	ok, wait := a.Reserve()
+	if ok {
+		t.Fatal("expected the shared budget to be exhausted")
+	}
+	approx(t, "wait", wait, time.Minute)
+
+	clock.advance(time.Minute)
+	if ok, _ := b.Reserve(); !ok {
+		t.Error("expected a slot once the window has passed")
+	}
+}
+
+func TestSharedRateLimiter_DropsBookingsOfDeadProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	l := newTestSharedLimiter(t, path, os.Getpid(), clock)
+
+	// A crashed process left two bookings in the future.
+	stale := sharedState{Slots: []sharedSlot{
+		{PID: -1, At: clock.now().Add(10 * time.Second)},
+		{PID: -1, At: clock.now().Add(20 * time.Second)},
+	}}
+	data, err := json.Marshal(stale)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, wait := l.Reserve(); !ok {
+		t.Fatalf("expected stale bookings to be discarded, still waiting %v", wait)
+	}
+	if s := readSharedState(t, path); len(s.Slots) != 1 {
+		t.Errorf("expected only the new booking to remain, got %+v", s.Slots)
+	}
+}
+
+func TestSharedRateLimiter_CanceledWaitReleasesBooking(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	l := newTestSharedLimiter(t, path, os.Getpid(), clock)
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+	if s := readSharedState(t, path); len(s.Slots) != 2 {
+		t.Errorf("expected the canceled booking to be removed, got %+v", s.Slots)
+	}
+}
+
+func TestSharedRateLimiter_429BlocksEveryProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	a := newTestSharedLimiter(t, path, os.Getpid(), clock)
+	b := newTestSharedLimiter(t, path, os.Getpid(), clock)
+
+	a.ObserveResponse(context.Background(), &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": {"5"}},
+	})
+	ok, wait := b.Reserve()
+	if ok {
+		t.Fatal("expected the other limiter to honor the shared block")
+	}
+	approx(t, "blocked wait", wait, 5*time.Second)
+}
+
+func TestSharedRateLimiter_429WithoutHintBlocksForOneInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	a := newTestSharedLimiter(t, path, os.Getpid(), clock)
+	b := newTestSharedLimiter(t, path, os.Getpid(), clock)
+
+	a.ObserveResponse(context.Background(), &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}})
+	if ok, _ := b.Reserve(); !ok {
+		t.Fatal("expected a 503 without a hint not to block")
+	}
+	a.ObserveResponse(context.Background(), &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}})
+	ok, wait := b.Reserve()
+	if ok {
+		t.Fatal("expected a 429 without a hint to block every process")
+	}
+	approx(t, "blocked wait", wait, a.window/time.Duration(a.limit))
+}
+
+func TestNewSharedRateLimiter_DefaultPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	a, err := NewSharedRateLimiter(SharedRateLimiterConfig{Name: "key-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := NewSharedRateLimiter(SharedRateLimiterConfig{Name: "key-2"})
+	if filepath.Dir(filepath.Dir(a.Path())) != dir {
+		t.Errorf("expected the state file under $XDG_RUNTIME_DIR, got %s", a.Path())
+	}
+	if a.Path() == b.Path() {
+		t.Error("expected different names to use different state files")
+	}
+}
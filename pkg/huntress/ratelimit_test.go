@@ -0,0 +1,212 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for limiter tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter(cfg TokenBucketConfig) (*TokenBucketLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	l := NewTokenBucketLimiter(cfg)
+	l.now = clock.now
+	return l, clock
+}
+
+func approx(t *testing.T, name string, got, want time.Duration) {
+	t.Helper()
+	if diff := got - want; diff < -10*time.Millisecond || diff > 10*time.Millisecond {
+		t.Errorf("%s: got %v, want %v", name, got, want)
+	}
+}
+
+func TestTokenBucketLimiter_Burst(t *testing.T) {
+	l, clock := newTestLimiter(TokenBucketConfig{Budget: Budget{Limit: 60, Period: time.Minute, Burst: 3}})
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Reserve(); !ok {
+			t.Fatalf("reservation %d: expected a token from the burst", i)
+		}
+	}
+	ok, wait := l.Reserve()
+	if ok {
+		t.Fatal("expected the burst to be exhausted")
+	}
+	approx(t, "wait", wait, time.Second)
+
+	clock.advance(time.Second)
+	if ok, _ := l.Reserve(); !ok {
+		t.Error("expected a token after one refill interval")
+	}
+}
+
+func TestTokenBucketLimiter_ServesHigherPriorityFirst(t *testing.T) {
+	l, clock := newTestLimiter(TokenBucketConfig{Budget: Budget{Limit: 60, Period: time.Minute, Burst: 1}})
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	order := make(chan Priority, 3)
+	for i, p := range []Priority{PriorityBulk, PriorityNormal, PriorityHigh} {
+		go func(p Priority) {
+			if err := l.Wait(WithPriority(context.Background(), p)); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			order <- p
+		}(p)
+		waitFor(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.queued() == i+1
+		})
+	}
+
+	for _, want := range []Priority{PriorityHigh, PriorityNormal, PriorityBulk} {
+		clock.advance(time.Second)
+		l.mu.Lock()
+		l.dispatch()
+		l.mu.Unlock()
+		if got := <-order; got != want {
+			t.Fatalf("expected %s to be served next, got %s", want, got)
+		}
+	}
+}
+
+func TestTokenBucketLimiter_BulkShare(t *testing.T) {
+	l, _ := newTestLimiter(TokenBucketConfig{
+		Budget:    Budget{Limit: 60, Period: time.Minute, Burst: 10},
+		BulkShare: 0.5,
+	})
+	bulk := WithPriority(context.Background(), PriorityBulk)
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(bulk); err != nil {
+			t.Fatalf("bulk request %d: unexpected error: %v", i, err)
+		}
+	}
+	short, cancel := context.WithTimeout(bulk, 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected bulk requests to be capped at half the burst, got %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("normal request %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestPriority_String(t *testing.T) {
+	for p, want := range map[Priority]string{PriorityHigh: "high", PriorityNormal: "normal", PriorityBulk: "bulk"} {
+		if got := p.String(); got != want {
+			t.Errorf("Priority(%d).String() = %q, want %q", p, got, want)
+		}
+	}
+	if got := PriorityFromContext(context.Background()); got != PriorityNormal {
+		t.Errorf("expected normal priority by default, got %s", got)
+	}
+}
+
+func TestTokenBucketLimiter_GroupBudget(t *testing.T) {
+	l, _ := newTestLimiter(TokenBucketConfig{
+		Budget: Budget{Limit: 60, Period: time.Minute, Burst: 10},
+		Groups: map[string]Budget{"/reports": {Limit: 1, Period: time.Minute}},
+	})
+	ctx := withEndpoint(context.Background(), "/reports/42/download")
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the report group to be exhausted, got %v", err)
+	}
+	if err := l.Wait(withEndpoint(context.Background(), "/agents")); err != nil {
+		t.Errorf("expected other endpoints to be unaffected, got %v", err)
+	}
+	if got := l.global.tokens; got < 7.9 || got > 8.1 {
+		t.Errorf("expected the canceled wait to refund its token, have %.2f tokens", got)
+	}
+}
+
+func TestTokenBucketLimiter_FollowsHeaders(t *testing.T) {
+	l, clock := newTestLimiter(TokenBucketConfig{})
+	l.ObserveResponse(context.Background(), &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"X-Ratelimit-Limit":     {"120"},
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {"30"},
+		},
+	})
+	ok, wait := l.Reserve()
+	if ok {
+		t.Fatal("expected no tokens while the server reports none remaining")
+	}
+	approx(t, "reset wait", wait, 30*time.Second)
+	if l.global.rate != 2 {
+		t.Errorf("expected the rate to follow X-RateLimit-Limit, got %v/s", l.global.rate)
+	}
+	clock.advance(30 * time.Second)
+	if ok, _ := l.Reserve(); !ok {
+		t.Error("expected tokens after the reset")
+	}
+}
+
+func TestTokenBucketLimiter_SlowsDownAfter429(t *testing.T) {
+	l, clock := newTestLimiter(TokenBucketConfig{Budget: Budget{Limit: 60, Period: time.Minute, Burst: 1}})
+	l.ObserveResponse(context.Background(), &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": {"2"}},
+	})
+	_, wait := l.Reserve()
+	approx(t, "retry-after wait", wait, 2*time.Second)
+
+	clock.advance(2 * time.Second)
+	_, wait = l.Reserve()
+	approx(t, "half-speed refill", wait, 2*time.Second)
+
+	clock.advance(2 * time.Second)
+	if ok, _ := l.Reserve(); !ok {
+		t.Error("expected a token after the slowed refill")
+	}
+}
+
+func TestClient_TokenBucketLimiter_ObservesResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "45")
+		if _, err := w.Write([]byte(`{"id":"1"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	limiter := NewTokenBucketLimiter(TokenBucketConfig{})
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithRateLimiter(limiter))
+	if _, err := client.Organization.Get(context.Background(), "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, wait := limiter.Reserve(); ok || wait < 40*time.Second {
+		t.Errorf("expected the limiter to honor the reset header, got ok=%v wait=%v", ok, wait)
+	}
+}
@@ -0,0 +1,122 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/redact"
+)
+
+// minLiteralSecretLen is the shortest credential scrubbed from log text by
+// value. Shorter ones, which only placeholder credentials are, would mangle
+// unrelated words.
+const minLiteralSecretLen = 8
+
+// newLogRedactor returns the redactor applied to everything the client
+// logs: credential-like keys, email addresses, and the client's own API
+// secret and Authorization token wherever they appear.
+func newLogRedactor(apiKey, apiSecret string) redact.Redactor {
+	r := redact.Redactor{Emails: true}
+	if len(apiSecret) >= minLiteralSecretLen {
+		r.Secrets = []string{apiSecret, basicAuth(apiKey, apiSecret)}
+	}
+	return r
+}
+
+// logger returns the client's Logger behind the client's redactor, or nil
+// when logging is disabled. New installs the redaction; wrapping again here
+// covers a Logger assigned after construction.
+func (c *Client) logger() logging.Logger {
+	if c.Logger == nil {
+		return nil
+	}
+	if _, ok := c.Logger.(*redactingLogger); ok {
+		return c.Logger
+	}
+	return &redactingLogger{next: c.Logger, r: c.redactor}
+}
+
+// redactingLogger redacts messages and field values before passing them to
+// the next Logger; see redact.Redactor.Value.
+type redactingLogger struct {
+	next logging.Logger
+	r    redact.Redactor
+}
+
+func (l *redactingLogger) fields(fields []logging.Field) []logging.Field {
+	out := make([]logging.Field, len(fields))
+	for i, f := range fields {
+		out[i] = logging.Field{Key: f.Key, Value: l.r.Value(f.Key, f.Value)}
+	}
+	return out
+}
+
+func (l *redactingLogger) Debug(msg string, fields ...logging.Field) {
+	l.next.Debug(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) Info(msg string, fields ...logging.Field) {
+	l.next.Info(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) Warn(msg string, fields ...logging.Field) {
+	l.next.Warn(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) Error(msg string, fields ...logging.Field) {
+	l.next.Error(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) Fatal(msg string, fields ...logging.Field) {
+	l.next.Fatal(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) WithContext(ctx context.Context) logging.Logger {
+	return &redactingLogger{next: l.next.WithContext(ctx), r: l.r}
+}
+
+func (l *redactingLogger) WithFields(fields ...logging.Field) logging.Logger {
+	return &redactingLogger{next: l.next.WithFields(l.fields(fields)...), r: l.r}
+}
+
+// logRequestBody logs a request's headers and body at debug level, for
+// WithDebug. Redaction is left to the logger.
+func logRequestBody(log logging.Logger, req *http.Request) {
+	body, err := requestBody(req)
+	if err != nil {
+		log.Debug("Request body unreadable", logging.Error("error", err))
+		return
+	}
+	log.Debug("Request body",
+		logging.Field{Key: "headers", Value: req.Header},
+		logging.Field{Key: "body", Value: body},
+	)
+}
+
+// logResponseBody buffers a response's body and logs it with the headers
+// at debug level, for WithDebug. A read error is logged and then returned
+// to whoever reads the replaced body.
+func logResponseBody(log logging.Logger, resp *http.Response) {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		log.Debug("Response body unreadable", logging.Int("status", resp.StatusCode), logging.Error("error", err))
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		return
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	log.Debug("Response body",
+		logging.Int("status", resp.StatusCode),
+		logging.Field{Key: "headers", Value: resp.Header},
+		logging.Field{Key: "body", Value: body},
+	)
+}
+
+// errReader fails every read with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -4,9 +4,7 @@ package huntress
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"time"
 )
 
@@ -106,30 +104,9 @@ func (s *reportService) Download(ctx context.Context, id string, format string)
 		return nil, fmt.Errorf("failed to create request for Download: %w", err)
 	}
 
-	// For downloads, we need to handle the raw response
-	if s.client.httpClient == nil {
-		return nil, fmt.Errorf("HTTP client is not initialized")
-	}
-
-	resp, err := s.client.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute HTTP request for Download: %w", err)
-	}
-	if resp != nil {
-		if err := resp.Body.Close(); err != nil {
-			return nil, fmt.Errorf("report download: error closing response body: %w", err)
-		}
-	}
-
-	// Check for errors
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("api error: status code %d", resp.StatusCode)
-	}
-
-	// Read the full response body
-	data, err := io.ReadAll(resp.Body)
+	data, err := s.client.doRaw(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body in Download: %w", err)
+		return nil, fmt.Errorf("failed to execute request for Download: %w", err)
 	}
 	return data, nil
 }
@@ -237,32 +214,9 @@ func (s *reportService) Export(ctx context.Context, params *ReportExportParams)
 		}
 	}
 
-	// For exports, we need to handle the raw response
-	if s.client.httpClient == nil {
-		return nil, fmt.Errorf("HTTP client is not initialized")
-	}
-
-	resp, err := s.client.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute HTTP request for Export: %w", err)
-	}
-	if resp != nil {
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				fmt.Fprintf(os.Stderr, "error closing response body: %v\n", err)
-			}
-		}()
-	}
-
-	// Check for errors
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("api error: status code %d", resp.StatusCode)
-	}
-
-	// Read the full response body
-	data, err := io.ReadAll(resp.Body)
+	data, err := s.client.doRaw(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body in Export: %w", err)
+		return nil, fmt.Errorf("failed to execute request for Export: %w", err)
 	}
 	return data, nil
 }
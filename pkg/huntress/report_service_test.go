@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // Use roundTripFunc from testhelpers_test.go (do not redeclare here)
@@ -44,4 +47,39 @@ func TestReportService_Generate_HTTPError(t *testing.T) {
 	}
 }
 
+func TestReportService_Download_UsesPipeline(t *testing.T) {
+	pdf := []byte("%PDF-1.7 not json")
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get(RequestIDHeader) == "" || r.URL.Query().Get("format") != "pdf" {
+			t.Errorf("unexpected request %s with headers %v", r.URL, r.Header)
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write(pdf)
+	}))
+	defer srv.Close()
+
+	var got []RequestMetrics
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL+"/v1"),
+		WithRetryConfig(2, time.Millisecond, time.Millisecond),
+		WithMetrics(MetricsFunc(func(m RequestMetrics) { got = append(got, m) })),
+	)
+	data, err := client.Report.Download(context.Background(), "r-1", "pdf")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if !bytes.Equal(data, pdf) {
+		t.Errorf("downloaded %q, want %q", data, pdf)
+	}
+	if len(got) != 1 || got[0].Attempts != 2 || got[0].Retries != 1 || got[0].Endpoint != "/reports/{id}/download" || got[0].ResponseBytes != int64(len(pdf)) {
+		t.Errorf("metrics = %+v, want one retried download", got)
+	}
+}
+
 // Additional tests for Get, List, Download, GetSummary, GetDetails, Schedule, Export would follow the same pattern.
@@ -0,0 +1,37 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+)
+
+// RequestIDHeader is the header that carries a request's ID. Every request
+// sent through the client has one: the ID given with the RequestID request
+// option, or a random UUID.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDFor returns the request ID to send with a request made with ctx.
+func requestIDFor(ctx context.Context) string {
+	if ro := requestOptionsFromContext(ctx); ro != nil && ro.requestID != "" {
+		return ro.requestID
+	}
+	return uuid.NewString()
+}
+
+// requestLogger returns the client's Logger with the request's ID attached,
+// or nil when logging is disabled.
+func (c *Client) requestLogger(req *http.Request) logging.Logger {
+	log := c.logger()
+	if log == nil {
+		return nil
+	}
+	if id := req.Header.Get(RequestIDHeader); id != "" {
+		return log.WithFields(logging.String("request_id", id))
+	}
+	return log
+}
@@ -0,0 +1,90 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClient_RequestID(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get(RequestIDHeader))
+		mu.Unlock()
+		if r.Method == http.MethodPatch {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":"INVALID","message":"bad name"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+	logger := &recordingLogger{}
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithLogger(logger))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Organization.Get(ctx, "1"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	if len(seen) != 2 || seen[0] == seen[1] || uuid.Validate(seen[0]) != nil {
+		t.Fatalf("request IDs = %q, want two distinct UUIDs", seen)
+	}
+	for _, msg := range []string{"Creating new request", "Sending request"} {
+		entries := logger.Entries(msg)
+		if len(entries) != 2 || entries[0].Fields["request_id"] != seen[0] || entries[1].Fields["request_id"] != seen[1] {
+			t.Errorf("%q lines = %+v, want the request IDs %q", msg, entries, seen)
+		}
+	}
+
+	// An ID from the context is sent as-is and cited by errors when the API
+	// reports none of its own
+	ctx = WithRequestOptions(ctx, RequestID("inbound-42"))
+	_, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "x"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID() != "inbound-42" || !strings.Contains(err.Error(), "inbound-42") {
+		t.Fatalf("error = %v, want it to cite request ID inbound-42", err)
+	}
+	if seen[2] != "inbound-42" {
+		t.Errorf("sent request ID %q, want inbound-42", seen[2])
+	}
+	if entries := logger.Entries("API error response"); len(entries) != 1 || entries[0].Fields["request_id"] != "inbound-42" {
+		t.Errorf("error log lines = %+v", entries)
+	}
+}
+
+func TestClient_RequestID_ServerReported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "srv-"+r.Header.Get(RequestIDHeader))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"))
+
+	ctx := WithRequestOptions(context.Background(), RequestID("abc"))
+	_, err := client.Agent.Get(ctx, "1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID() != "srv-abc" {
+		t.Fatalf("error = %v, want the server's request ID srv-abc", err)
+	}
+}
+
+func TestClient_RequestID_TransportError(t *testing.T) {
+	client := New(WithCredentials("k", "s"), WithHTTPClient(&http.Client{Transport: errTransport{errors.New("connection refused")}}))
+	ctx := WithRequestOptions(context.Background(), RequestID("xyz"), RequestTimeout(time.Second))
+	_, err := client.Account.Get(ctx)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.RequestID() != "xyz" || !strings.Contains(err.Error(), "request ID: xyz") {
+		t.Fatalf("error = %v, want a RequestError citing xyz", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader is the header that carries a request's idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RequestOption customizes a single API call. Options travel on the call's
+// context, so they work with every service method:
+//
+//	ctx = huntress.WithRequestOptions(ctx, huntress.RequestTimeout(5*time.Second), huntress.BypassCache())
+//	org, err := client.Organization.Get(ctx, id)
+type RequestOption func(*requestOptions)
+
+// requestOptions is the accumulated state of a call's RequestOptions.
+type requestOptions struct {
+	header         http.Header
+	query          url.Values
+	timeout        time.Duration
+	bypassCache    bool
+	priority       *Priority
+	idempotencyKey string
+	requestID      string
+	dryRun         *bool
+}
+
+type requestOptionsCtxKey struct{}
+
+// WithRequestOptions returns a context that applies opts to every request
+// sent with it. Options already on ctx are kept; later options win.
+func WithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	ro := requestOptions{header: http.Header{}, query: url.Values{}}
+	if prev := requestOptionsFromContext(ctx); prev != nil {
+		ro = *prev
+		ro.header = prev.header.Clone()
+		ro.query = cloneValues(prev.query)
+	}
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	return context.WithValue(ctx, requestOptionsCtxKey{}, &ro)
+}
+
+func requestOptionsFromContext(ctx context.Context) *requestOptions {
+	ro, _ := ctx.Value(requestOptionsCtxKey{}).(*requestOptions)
+	return ro
+}
+
+// RequestHeader sets a header on the request, replacing any value the
+// client would set.
+func RequestHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// RequestQuery adds a query parameter to the request URL.
+func RequestQuery(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.query.Add(key, value)
+	}
+}
+
+// RequestTimeout bounds the call, including retries and reading the
+// response, by d. It only shortens a deadline already on the context.
+func RequestTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// BypassCache makes a GET skip the response cache and request coalescing.
+// The fresh response still replaces the cached one.
+func BypassCache() RequestOption {
+	return func(o *requestOptions) {
+		o.bypassCache = true
+	}
+}
+
+// RequestPriority sets the priority the request waits at in rate limiters
+// that support it; see WithPriority.
+func RequestPriority(p Priority) RequestOption {
+	return func(o *requestOptions) {
+		o.priority = &p
+	}
+}
+
+// IdempotencyKey sends key in the Idempotency-Key header so the API can
+// recognize repeats of the same operation. POST requests get a random key
+// when none is given. The client retries POST requests that carry a key,
+// and every attempt sends the same one, so a retried create does not
+// create a duplicate.
+func IdempotencyKey(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// RequestID sends id in the X-Request-ID header instead of a generated ID,
+// for example to reuse the ID of the inbound request being served so logs on
+// both sides line up. The ID is attached to the client's log lines and to
+// errors from the call.
+func RequestID(id string) RequestOption {
+	return func(o *requestOptions) {
+		o.requestID = id
+	}
+}
+
+// DryRun overrides the client's dry-run setting for the call; see
+// WithDryRun. DryRun(true) plans a single mutation on a live client, and
+// DryRun(false) sends one from a dry-run client.
+func DryRun(enabled bool) RequestOption {
+	return func(o *requestOptions) {
+		o.dryRun = &enabled
+	}
+}
+
+// applyRequestOptions returns req with the RequestOptions on its context
+// applied, and a function that releases the per-call timeout, if any. POST
+// requests without an idempotency key are given one, and requests without a
+// request ID are given one.
+func applyRequestOptions(req *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	ro := requestOptionsFromContext(ctx)
+	needsKey := req.Method == http.MethodPost && req.Header.Get(IdempotencyKeyHeader) == ""
+	needsID := req.Header.Get(RequestIDHeader) == ""
+	if ro == nil && !needsKey && !needsID {
+		return req, cancel
+	}
+	if ro != nil {
+		if ro.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, ro.timeout)
+		}
+		if ro.priority != nil {
+			ctx = WithPriority(ctx, *ro.priority)
+		}
+	}
+	req = req.Clone(ctx)
+	if ro != nil {
+		for k, v := range ro.header {
+			req.Header[k] = append([]string(nil), v...)
+		}
+		if len(ro.query) > 0 {
+			q := req.URL.Query()
+			for k, v := range ro.query {
+				q[k] = append(q[k], v...)
+			}
+			req.URL.RawQuery = q.Encode()
+		}
+		if ro.idempotencyKey != "" {
+			req.Header.Set(IdempotencyKeyHeader, ro.idempotencyKey)
+		}
+		if ro.requestID != "" {
+			req.Header.Set(RequestIDHeader, ro.requestID)
+		}
+	}
+	if needsKey && req.Header.Get(IdempotencyKeyHeader) == "" {
+		req.Header.Set(IdempotencyKeyHeader, uuid.NewString())
+	}
+	if req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, uuid.NewString())
+	}
+	return req, cancel
+}
+
+// cacheBypassed reports whether the request asked to skip the cache.
+func cacheBypassed(req *http.Request) bool {
+	ro := requestOptionsFromContext(req.Context())
+	return ro != nil && ro.bypassCache
+}
+
+func cloneValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for k, vs := range v {
+		out[k] = append([]string(nil), vs...)
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newHeaderRecordingServer answers every request with an organization and
+// records the requests it received.
+func newHeaderRecordingServer(t *testing.T, status int) (*httptest.Server, func() []*http.Request) {
+	t.Helper()
+	var mu sync.Mutex
+	var reqs []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqs = append(reqs, r.Clone(context.Background()))
+		mu.Unlock()
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(`{"id":"1","name":"Acme"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []*http.Request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*http.Request(nil), reqs...)
+	}
+}
+
+func TestRequestOptions_HeadersAndQuery(t *testing.T) {
+	srv, received := newHeaderRecordingServer(t, http.StatusOK)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+	ctx := WithRequestOptions(context.Background(), RequestHeader("X-Tenant", "a"))
+	ctx = WithRequestOptions(ctx, RequestHeader("User-Agent", "sync-job/2"), RequestQuery("include", "users"))
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := received()[0]
+	if req.Header.Get("X-Tenant") != "a" || req.Header.Get("User-Agent") != "sync-job/2" {
+		t.Errorf("expected the request headers to be applied, got %v", req.Header)
+	}
+	if got := req.URL.Query().Get("include"); got != "users" {
+		t.Errorf("expected the query parameter, got %q", got)
+	}
+}
+
+func TestRequestOptions_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv, _ := newBlockingServer(t, release)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL))
+	ctx := WithRequestOptions(context.Background(), RequestTimeout(20*time.Millisecond))
+	if _, err := client.Organization.Get(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the per-call timeout to apply, got %v", err)
+	}
+}
+
+func TestRequestOptions_BypassCache(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithCacheTTL(time.Minute))
+	ctx := context.Background()
+	for _, c := range []context.Context{ctx, WithRequestOptions(ctx, BypassCache()), ctx} {
+		if _, _, err := client.Organization.List(c, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := callCount(calls, "GET /organizations"); got != 2 {
+		t.Errorf("expected only the bypassing call to reach the API again, got %d calls", got)
+	}
+}
+
+func TestRequestOptions_IdempotencyKey(t *testing.T) {
+	srv, received := newHeaderRecordingServer(t, http.StatusServiceUnavailable)
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL),
+		WithRetryConfig(1, time.Millisecond, time.Millisecond))
+	params := &OrganizationCreateParams{Name: "acme"}
+
+	if _, err := client.Organization.Create(context.Background(), params); err == nil {
+		t.Fatal("expected the create to fail")
+	}
+	reqs := received()
+	if len(reqs) != 2 {
+		t.Fatalf("expected a retried create, got %d requests", len(reqs))
+	}
+	key := reqs[0].Header.Get(IdempotencyKeyHeader)
+	if key == "" || reqs[1].Header.Get(IdempotencyKeyHeader) != key {
+		t.Errorf("expected both attempts to carry the same generated key, got %q and %q", key, reqs[1].Header.Get(IdempotencyKeyHeader))
+	}
+
+	ctx := WithRequestOptions(context.Background(), IdempotencyKey("create-acme"))
+	if _, err := client.Organization.Create(ctx, params); err == nil {
+		t.Fatal("expected the create to fail")
+	}
+	if got := received(); len(got) != 4 || got[2].Header.Get(IdempotencyKeyHeader) != "create-acme" || got[3].Header.Get(IdempotencyKeyHeader) != "create-acme" {
+		t.Errorf("expected the caller's key on both attempts, got %d requests", len(got))
+	}
+	if _, err := client.Organization.Get(context.Background(), "1"); err == nil {
+		t.Fatal("expected the get to fail")
+	}
+	if got := received()[4].Header.Get(IdempotencyKeyHeader); got != "" {
+		t.Errorf("expected no key on GET requests, got %q", got)
+	}
+}
+
+func TestRequestOptions_Priority(t *testing.T) {
+	var seen Priority
+	client := New(WithCredentials("k", "s"), WithBaseURL("http://example.invalid"),
+		WithRateLimiter(priorityRecorder{&seen}),
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(func(_ *http.Request) *http.Response {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}
+		})}))
+	ctx := WithRequestOptions(context.Background(), RequestPriority(PriorityBulk))
+	if _, err := client.Organization.Get(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen != PriorityBulk {
+		t.Errorf("expected the limiter to see bulk priority, got %s", seen)
+	}
+}
+
+// priorityRecorder is a RateLimiter that records the priority of each wait.
+type priorityRecorder struct{ seen *Priority }
+
+func (r priorityRecorder) Wait(ctx context.Context) error {
+	*r.seen = PriorityFromContext(ctx)
+	return nil
+}
+
+func (r priorityRecorder) Reserve() (bool, time.Duration) { return true, 0 }
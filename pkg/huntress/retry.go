@@ -0,0 +1,66 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/http/retry"
+)
+
+// newRetrier converts the public retry options into an internal Retrier.
+func newRetrier(cfg *retryConfig) *retry.Retrier {
+	if cfg == nil {
+		return nil
+	}
+	return retry.NewRetrier(retry.Config{
+		MaxRetries:           cfg.MaxRetries,
+		BaseDelay:            cfg.RetryWaitMin,
+		MaxDelay:             cfg.RetryWaitMax,
+		RetryableStatusCodes: retry.DefaultConfig.RetryableStatusCodes,
+		MaxElapsedTime:       cfg.MaxElapsed,
+	})
+}
+
+// isIdempotent reports whether repeating a request with the given method is
+// safe, per RFC 9110 section 9.2.2.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// canRewind reports whether the request body can be replayed for another attempt.
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// shouldRetry reports whether the client may retry req. A POST carrying an
+// Idempotency-Key is safe to replay, since the API recognizes the repeat.
+func (c *Client) shouldRetry(req *http.Request) bool {
+	if c.retrier == nil || !canRewind(req) {
+		return false
+	}
+	if req.Method == http.MethodPost && req.Header.Get(IdempotencyKeyHeader) != "" {
+		return true
+	}
+	return isIdempotent(req.Method) || c.retryNonIdempotent
+}
+
+// attemptRequest returns the request to send for the given attempt. The first
+// attempt uses req as-is; later attempts get a clone with a fresh body.
+func attemptRequest(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("rewinding request body: %w", err)
+	}
+	clone := req.Clone(ctx)
+	clone.Body = body
+	return clone, nil
+}
@@ -0,0 +1,143 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryTestServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(`{"id":"org-1"}`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestClient_Do_RetriesServerErrors(t *testing.T) {
+	srv, calls := newRetryTestServer(t, 2, http.StatusServiceUnavailable, nil)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithRetryConfig(3, time.Millisecond, 5*time.Millisecond),
+	)
+	org, err := client.Organization.Get(context.Background(), "org-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if org.ID != "org-1" {
+		t.Errorf("expected org-1, got %q", org.ID)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestClient_Do_HonorsRetryAfter(t *testing.T) {
+	srv, calls := newRetryTestServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithRetryConfig(2, time.Millisecond, 2*time.Second),
+	)
+	start := time.Now()
+	if _, err := client.Organization.Get(context.Background(), "org-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("expected to wait for Retry-After, only waited %v", elapsed)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestClient_Do_RetryAfterBeyondMaxDelay(t *testing.T) {
+	srv, calls := newRetryTestServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"3600"}})
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithRetryConfig(2, time.Millisecond, 5*time.Millisecond),
+	)
+	start := time.Now()
+	_, err := client.Organization.Get(context.Background(), "org-1")
+	var rl *RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter() != 3600 {
+		t.Fatalf("expected a RateLimitError asking for an hour, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to return without waiting, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestClient_Do_DoesNotRetryPATCHByDefault(t *testing.T) {
+	srv, calls := newRetryTestServer(t, 1, http.StatusServiceUnavailable, nil)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithRetryConfig(3, time.Millisecond, 5*time.Millisecond),
+	)
+	_, err := client.Organization.Update(context.Background(), "org-1", &OrganizationUpdateParams{Name: "acme"})
+	if err == nil {
+		t.Fatal("expected error for 503 without retry")
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestClient_Do_RetriesPATCHWhenOptedIn(t *testing.T) {
+	srv, calls := newRetryTestServer(t, 1, http.StatusServiceUnavailable, nil)
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithRetryConfig(3, time.Millisecond, 5*time.Millisecond),
+		WithRetryNonIdempotent(true),
+	)
+	if _, err := client.Organization.Update(context.Background(), "org-1", &OrganizationUpdateParams{Name: "acme"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestClient_Do_MaxRetryDuration(t *testing.T) {
+	srv, calls := newRetryTestServer(t, 10, http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}})
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL),
+		WithRetryConfig(5, time.Millisecond, 5*time.Millisecond),
+		WithMaxRetryDuration(time.Second),
+	)
+	start := time.Now()
+	if _, err := client.Organization.Get(context.Background(), "org-1"); err == nil {
+		t.Fatal("expected error once the retry budget is exhausted")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to give up within the budget, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
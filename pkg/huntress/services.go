@@ -3,6 +3,7 @@ package huntress
 
 import (
 	"context"
+	"iter"
 )
 
 // AccountService handles Huntress account operations
@@ -40,6 +41,13 @@ type OrganizationService interface {
 	// List returns all organizations with optional filtering
 	List(ctx context.Context, params *ListOrganizationsParams) ([]*Organization, *Pagination, error)
 
+	// All iterates over every organization matching params, fetching pages lazily
+	All(ctx context.Context, params *ListOrganizationsParams) iter.Seq2[*Organization, error]
+
+	// AllParallel iterates over every organization matching params, fetching the
+	// remaining pages concurrently after the first
+	AllParallel(ctx context.Context, params *ListOrganizationsParams, opts *PrefetchOptions) iter.Seq2[*Organization, error]
+
 	// Create creates a new organization
 	Create(ctx context.Context, org *OrganizationCreateParams) (*Organization, error)
 
@@ -70,12 +78,23 @@ type AgentService interface {
 	// List returns all agents with optional filtering
 	List(ctx context.Context, params *AgentListOptions) ([]*Agent, *Pagination, error)
 
+	// All iterates over every agent matching params, fetching pages lazily
+	All(ctx context.Context, params *AgentListOptions) iter.Seq2[*Agent, error]
+
+	// AllParallel iterates over every agent matching params, fetching the
+	// remaining pages concurrently after the first
+	AllParallel(ctx context.Context, params *AgentListOptions, opts *PrefetchOptions) iter.Seq2[*Agent, error]
+
 	// GetStats retrieves statistics for a specific agent
 	GetStats(ctx context.Context, id string) (*AgentStatistics, error)
 
-	// Update updates an existing agent
+	// Update updates an existing agent from raw fields. Prefer Patch, which
+	// validates the update before sending it
 	Update(ctx context.Context, id string, agent map[string]interface{}) (*Agent, error)
 
+	// Patch applies a typed partial update, encoded as format
+	Patch(ctx context.Context, id string, params *AgentUpdateParams, format PatchFormat) (*Agent, error)
+
 	// Delete removes an agent
 	Delete(ctx context.Context, id string) error
 }
@@ -88,6 +107,13 @@ type IncidentService interface {
 	// List returns all incidents with optional filtering
 	List(ctx context.Context, params *IncidentListOptions) ([]*Incident, *Pagination, error)
 
+	// All iterates over every incident matching params, fetching pages lazily
+	All(ctx context.Context, params *IncidentListOptions) iter.Seq2[*Incident, error]
+
+	// AllParallel iterates over every incident matching params, fetching the
+	// remaining pages concurrently after the first
+	AllParallel(ctx context.Context, params *IncidentListOptions, opts *PrefetchOptions) iter.Seq2[*Incident, error]
+
 	// UpdateStatus updates the status of an incident
 	UpdateStatus(ctx context.Context, id string, status string) (*Incident, error)
 
@@ -130,6 +156,9 @@ type BillingService interface {
 	// ListInvoices lists all invoices
 	ListInvoices(ctx context.Context, params *ListParams) ([]*Invoice, *Pagination, error)
 
+	// AllInvoices iterates over every invoice, fetching pages lazily
+	AllInvoices(ctx context.Context, params *ListParams) iter.Seq2[*Invoice, error]
+
 	// GetInvoice retrieves a specific invoice
 	GetInvoice(ctx context.Context, id string) (*Invoice, error)
 
@@ -0,0 +1,145 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/redact"
+)
+
+// LevelFatal is the slog level SlogLogger logs Fatal messages at.
+const LevelFatal = slog.LevelError + 4
+
+// SlogLogger adapts a log/slog handler to the client's Logger interface.
+// Everything it logs passes through NewRedactingHandler first. Use it with
+// WithLogger, or pass the handler to WithSlogHandler.
+type SlogLogger struct {
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+// NewSlogLogger creates a SlogLogger writing to h, or to the default slog
+// handler when h is nil.
+func NewSlogLogger(h slog.Handler) *SlogLogger {
+	if h == nil {
+		h = slog.Default().Handler()
+	}
+	return &SlogLogger{logger: slog.New(NewRedactingHandler(h)), ctx: context.Background()}
+}
+
+// Handler returns the redacting handler the logger writes to.
+func (l *SlogLogger) Handler() slog.Handler { return l.logger.Handler() }
+
+func (l *SlogLogger) log(level slog.Level, msg string, fields []logging.Field) {
+	if !l.logger.Enabled(l.ctx, level) {
+		return
+	}
+	l.logger.LogAttrs(l.ctx, level, msg, slogAttrs(fields)...)
+}
+
+// Debug logs at slog.LevelDebug.
+func (l *SlogLogger) Debug(msg string, fields ...logging.Field) { l.log(slog.LevelDebug, msg, fields) }
+
+// Info logs at slog.LevelInfo.
+func (l *SlogLogger) Info(msg string, fields ...logging.Field) { l.log(slog.LevelInfo, msg, fields) }
+
+// Warn logs at slog.LevelWarn.
+func (l *SlogLogger) Warn(msg string, fields ...logging.Field) { l.log(slog.LevelWarn, msg, fields) }
+
+// Error logs at slog.LevelError.
+func (l *SlogLogger) Error(msg string, fields ...logging.Field) { l.log(slog.LevelError, msg, fields) }
+
+// Fatal logs at LevelFatal and exits the program, like the logging
+// package's StandardLogger. The client itself never calls it.
+func (l *SlogLogger) Fatal(msg string, fields ...logging.Field) {
+	l.log(LevelFatal, msg, fields)
+	os.Exit(1)
+}
+
+// WithContext returns a logger that passes ctx to the handler, so handlers
+// can pick up values such as trace IDs from it.
+func (l *SlogLogger) WithContext(ctx context.Context) logging.Logger {
+	return &SlogLogger{logger: l.logger, ctx: ctx}
+}
+
+// WithFields returns a logger that adds fields to every message.
+func (l *SlogLogger) WithFields(fields ...logging.Field) logging.Logger {
+	args := make([]any, len(fields))
+	for i, a := range slogAttrs(fields) {
+		args[i] = a
+	}
+	return &SlogLogger{logger: l.logger.With(args...), ctx: l.ctx}
+}
+
+func slogAttrs(fields []logging.Field) []slog.Attr {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	return attrs
+}
+
+// NewRedactingHandler wraps any slog.Handler so that records are redacted
+// before they reach it. Attributes with credential-like keys (password,
+// secret, token, apikey, authorization, ...) are replaced with
+// "[REDACTED]", email addresses are scrubbed from messages and values, and
+// structured values such as *account.Account are redacted field by field,
+// which covers WebhookPassword. Use it to apply the client's redaction to
+// handlers shared with the rest of a program.
+func NewRedactingHandler(h slog.Handler) slog.Handler {
+	if rh, ok := h.(*redactingHandler); ok {
+		return rh
+	}
+	return &redactingHandler{next: h, r: redact.Redactor{Emails: true}}
+}
+
+// redactingHandler is the slog.Handler returned by NewRedactingHandler.
+type redactingHandler struct {
+	next slog.Handler
+	r    redact.Redactor
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, rec slog.Record) error {
+	out := slog.NewRecord(rec.Time, rec.Level, h.r.String(rec.Message), rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.attr(a)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted), r: h.r}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), r: h.r}
+}
+
+// attr redacts a, descending into groups.
+func (h *redactingHandler) attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Key != "" && h.r.IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, redact.Placeholder)
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = h.attr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	}
+	return slog.Any(a.Key, h.r.Value(a.Key, a.Value.Any()))
+}
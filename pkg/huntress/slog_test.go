@@ -0,0 +1,99 @@
+package huntress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/domain/account"
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/redact"
+)
+
+func TestClient_DebugLogging(t *testing.T) {
+	const secret = "sk-live-0123456789"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"code":"INVALID","message":"owner ops@acme.example rejected","details":{"webhookPassword":"hunter2"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"1","name":"Acme","contact_info":{"email":"ops@acme.example"},"webhookPassword":"hunter2"}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	client := New(
+		WithCredentials("key-id", secret),
+		WithBaseURL(srv.URL+"/v1"),
+		WithSlogHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		WithDebug(true),
+	)
+	ctx := context.Background()
+	if _, err := client.Account.Get(ctx); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := client.Organization.Update(ctx, "1", &OrganizationUpdateParams{Name: "x"}); err == nil {
+		t.Fatal("Update succeeded, want a validation error")
+	}
+
+	out := buf.String()
+	for _, leak := range []string{secret, basicAuth("key-id", secret), "ops@acme.example", "hunter2"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("log output contains %q:\n%s", leak, out)
+		}
+	}
+	lines := map[string][]map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("malformed log line %q: %v", line, err)
+		}
+		lines[rec["msg"].(string)] = append(lines[rec["msg"].(string)], rec)
+	}
+	reqs, resps := lines["Request body"], lines["Response body"]
+	if len(reqs) != 2 || len(resps) != 2 {
+		t.Fatalf("got %d request and %d response dumps, want 2 of each:\n%s", len(reqs), len(resps), out)
+	}
+	if auth := reqs[0]["headers"].(map[string]interface{})["Authorization"]; auth.([]interface{})[0] != redact.Placeholder {
+		t.Errorf("Authorization = %v, want it redacted", auth)
+	}
+	if reqs[1]["body"] != `{"name":"x"}` || reqs[1]["request_id"] == nil {
+		t.Errorf("PATCH dump = %v, want the body and request ID", reqs[1])
+	}
+	if want := `{"contact_info":{"email":"redacted@example.com"},"id":"1","name":"Acme","webhookPassword":"[REDACTED]"}`; resps[0]["body"] != want {
+		t.Errorf("response body = %v, want %s", resps[0]["body"], want)
+	}
+	if len(lines["API error response"]) != 1 {
+		t.Errorf("missing API error line:\n%s", out)
+	}
+}
+
+func TestNewRedactingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewRedactingHandler(slog.NewTextHandler(&buf, nil)))
+	log.With("api_secret", "s1").WithGroup("call").Info("signed in as jo@corp.example.org",
+		"account", &account.Account{Name: "Acme", WebhookPassword: "hunter2", PrimaryContact: account.Contact{Email: "jo@corp.example.org"}},
+		slog.Group("auth", "Authorization", "Basic abc", "user", "jo"),
+		"status", 200,
+	)
+
+	out := buf.String()
+	for _, leak := range []string{"s1", "hunter2", "jo@corp.example.org", "Basic abc"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("output contains %q: %s", leak, out)
+		}
+	}
+	for _, want := range []string{"api_secret=[REDACTED]", "signed in as redacted@example.com", "webhookPassword:[REDACTED]", "call.auth.user=jo", "call.status=200"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q: %s", want, out)
+		}
+	}
+	if NewRedactingHandler(log.Handler()) != log.Handler() {
+		t.Error("wrapping a redacting handler again should return it unchanged")
+	}
+}
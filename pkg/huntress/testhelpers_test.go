@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/logging"
 )
 
 type roundTripFunc func(_ *http.Request) *http.Response
@@ -55,3 +58,57 @@ func (c *testClient) NewRequest(ctx context.Context, method, path string, body i
 	req.Header.Set("User-Agent", c.userAgent)
 	return req, nil
 }
+
+// logEntry is a line captured by recordingLogger.
+type logEntry struct {
+	Level  string
+	Msg    string
+	Fields map[string]interface{}
+}
+
+// recordingLogger is a logging.Logger that keeps every line for inspection.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+	fields  []logging.Field
+	parent  *recordingLogger
+}
+
+func (l *recordingLogger) log(level, msg string, fields []logging.Field) {
+	root := l
+	for root.parent != nil {
+		root = root.parent
+	}
+	e := logEntry{Level: level, Msg: msg, Fields: make(map[string]interface{})}
+	for _, f := range append(append([]logging.Field(nil), l.fields...), fields...) {
+		e.Fields[f.Key] = f.Value
+	}
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.entries = append(root.entries, e)
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...logging.Field) { l.log("debug", msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...logging.Field)  { l.log("info", msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...logging.Field)  { l.log("warn", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...logging.Field) { l.log("error", msg, fields) }
+func (l *recordingLogger) Fatal(msg string, fields ...logging.Field) { l.log("fatal", msg, fields) }
+
+func (l *recordingLogger) WithContext(_ context.Context) logging.Logger { return l }
+
+func (l *recordingLogger) WithFields(fields ...logging.Field) logging.Logger {
+	return &recordingLogger{fields: append(append([]logging.Field(nil), l.fields...), fields...), parent: l}
+}
+
+// Entries returns the captured lines with the given message.
+func (l *recordingLogger) Entries(msg string) []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []logEntry
+	for _, e := range l.entries {
+		if e.Msg == msg {
+			out = append(out, e)
+		}
+	}
+	return out
+}
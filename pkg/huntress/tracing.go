@@ -0,0 +1,221 @@
+// Package huntress provides a client for the Huntress API
+package huntress
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TraceParentHeader is the W3C Trace Context header the tracing stage sets
+// on outgoing requests.
+const TraceParentHeader = "traceparent"
+
+// Span attribute keys set by the tracing stage. The standard keys follow
+// the OpenTelemetry HTTP semantic conventions.
+const (
+	AttrHTTPMethod     = "http.request.method"
+	AttrEndpoint       = "url.template"
+	AttrHTTPStatusCode = "http.response.status_code"
+	AttrAttempts       = "huntress.attempts"
+	AttrCache          = "huntress.cache"
+)
+
+// Tracer starts spans for API calls. Implement it to adapt the client to a
+// tracing backend such as OpenTelemetry; InMemoryTracer is provided for
+// tests.
+type Tracer interface {
+	// Start starts a span named name as a child of any span in ctx and
+	// returns a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one traced API call.
+type Span interface {
+	// SetAttribute records a key/value pair on the span.
+	SetAttribute(key string, value interface{})
+	// RecordError marks the span as failed.
+	RecordError(err error)
+	// TraceParent returns the span's W3C traceparent header value, such as
+	// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", or "" to
+	// send none.
+	TraceParent() string
+	// End completes the span.
+	End()
+}
+
+// tracingStage starts a span per API call, named after the method and
+// endpoint template, and propagates it to the API with a traceparent
+// header. Attempts and the cache outcome are read from the call's stats
+// once the inner stages have run.
+func (c *Client) tracingStage(next Doer) Doer {
+	if c.tracer == nil {
+		return next
+	}
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		stats := callStatsFromContext(ctx)
+		if stats == nil {
+			stats = &callStats{}
+			ctx = withCallStats(ctx, stats)
+		}
+		endpoint := EndpointTemplate(c.endpointPath(req.URL))
+		ctx, span := c.tracer.Start(ctx, req.Method+" "+endpoint)
+		defer span.End()
+		span.SetAttribute(AttrHTTPMethod, req.Method)
+		span.SetAttribute(AttrEndpoint, endpoint)
+
+		req = req.Clone(ctx)
+		if tp := span.TraceParent(); tp != "" {
+			req.Header.Set(TraceParentHeader, tp)
+		}
+		resp, err := next.Do(req)
+		span.SetAttribute(AttrAttempts, int(stats.attempts.Load()))
+		if err != nil {
+			span.RecordError(err)
+			return resp, err
+		}
+		span.SetAttribute(AttrHTTPStatusCode, resp.StatusCode)
+		if cache := CacheStatus(resp); cache != "" {
+			span.SetAttribute(AttrCache, cache)
+		}
+		if resp.StatusCode >= 400 {
+			span.RecordError(fmt.Errorf("API responded %s", resp.Status))
+		}
+		return resp, nil
+	})
+}
+
+// InMemoryTracer is a Tracer that keeps finished spans in memory, for
+// asserting on the calls a test made. It is safe for concurrent use.
+type InMemoryTracer struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+// RecordedSpan is a span finished by an InMemoryTracer.
+type RecordedSpan struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	ParentID   string // "" for a root span
+	Attributes map[string]interface{}
+	Err        error
+	Start      time.Time
+	End        time.Time
+}
+
+// NewInMemoryTracer creates an InMemoryTracer.
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{}
+}
+
+// inMemorySpanCtxKey carries the current *inMemorySpan.
+type inMemorySpanCtxKey struct{}
+
+// Start implements Tracer.
+func (t *InMemoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &inMemorySpan{
+		tracer: t,
+		rec: RecordedSpan{
+			Name:       name,
+			SpanID:     randomHex(8),
+			Attributes: make(map[string]interface{}),
+			Start:      time.Now(),
+		},
+	}
+	if parent, ok := ctx.Value(inMemorySpanCtxKey{}).(*inMemorySpan); ok {
+		s.rec.TraceID = parent.rec.TraceID
+		s.rec.ParentID = parent.rec.SpanID
+	} else {
+		s.rec.TraceID = randomHex(16)
+	}
+	return context.WithValue(ctx, inMemorySpanCtxKey{}, s), s
+}
+
+// Spans returns the spans finished so far, in the order they ended.
+func (t *InMemoryTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]RecordedSpan(nil), t.spans...)
+}
+
+// Reset discards the recorded spans.
+func (t *InMemoryTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+// inMemorySpan is a Span started by an InMemoryTracer.
+type inMemorySpan struct {
+	tracer *InMemoryTracer
+
+	mu    sync.Mutex
+	rec   RecordedSpan
+	ended bool
+}
+
+func (s *inMemorySpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rec.Attributes[key] = value
+}
+
+func (s *inMemorySpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rec.Err = err
+}
+
+func (s *inMemorySpan) TraceParent() string {
+	return FormatTraceParent(s.rec.TraceID, s.rec.SpanID, true)
+}
+
+func (s *inMemorySpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.rec.End = time.Now()
+	rec := s.rec
+	rec.Attributes = make(map[string]interface{}, len(s.rec.Attributes))
+	for k, v := range s.rec.Attributes {
+		rec.Attributes[k] = v
+	}
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, rec)
+}
+
+// FormatTraceParent builds a version 00 W3C traceparent value from a
+// 32-digit hex trace ID and a 16-digit hex span ID.
+func FormatTraceParent(traceID, spanID string, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return "00-" + traceID + "-" + spanID + "-" + flags
+}
+
+// randomHex returns n random bytes as hex, never all zeros, which W3C
+// Trace Context reserves as invalid for trace and span IDs.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	for {
+		_, _ = rand.Read(b) // crypto/rand.Read never returns an error
+		for _, c := range b {
+			if c != 0 {
+				return hex.EncodeToString(b)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_Tracing(t *testing.T) {
+	var mu sync.Mutex
+	var traceparents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents = append(traceparents, r.Header.Get(TraceParentHeader))
+		first := len(traceparents) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"7"}`))
+	}))
+	defer srv.Close()
+
+	tracer := NewInMemoryTracer()
+	client := New(
+		WithCredentials("k", "s"),
+		WithBaseURL(srv.URL+"/v1"),
+		WithCacheTTL(time.Minute),
+		WithRetryConfig(2, time.Millisecond, time.Millisecond),
+		WithTracer(tracer),
+	)
+
+	ctx, page := tracer.Start(context.Background(), "GET /dashboard")
+	for i := 0; i < 2; i++ {
+		if _, err := client.Agent.Get(ctx, "7"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	page.End()
+
+	spans := tracer.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 2 calls and the parent", len(spans))
+	}
+	fetched, cached, parent := spans[0], spans[1], spans[2]
+	for _, s := range []RecordedSpan{fetched, cached} {
+		if s.Name != "GET /agents/{id}" || s.TraceID != parent.TraceID || s.ParentID != parent.SpanID {
+			t.Errorf("span %q (trace %s, parent %s) is not a child of %s/%s", s.Name, s.TraceID, s.ParentID, parent.TraceID, parent.SpanID)
+		}
+		if s.Attributes[AttrHTTPMethod] != http.MethodGet || s.Attributes[AttrEndpoint] != "/agents/{id}" || s.Attributes[AttrHTTPStatusCode] != http.StatusOK {
+			t.Errorf("span attributes = %v", s.Attributes)
+		}
+	}
+	if fetched.Attributes[AttrAttempts] != 2 || fetched.Attributes[AttrCache] != CacheMiss || fetched.Err != nil {
+		t.Errorf("fetched span attributes = %v, err %v; want 2 attempts and a miss", fetched.Attributes, fetched.Err)
+	}
+	if cached.Attributes[AttrAttempts] != 0 || cached.Attributes[AttrCache] != CacheHit {
+		t.Errorf("cached span attributes = %v, want 0 attempts and a hit", cached.Attributes)
+	}
+
+	want := FormatTraceParent(fetched.TraceID, fetched.SpanID, true)
+	if !regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`).MatchString(want) {
+		t.Errorf("malformed traceparent %q", want)
+	}
+	if len(traceparents) != 2 || traceparents[0] != want || traceparents[1] != want {
+		t.Errorf("server saw traceparents %q, want %q on both attempts", traceparents, want)
+	}
+}
+
+// errTransport fails every request with err.
+type errTransport struct{ err error }
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) { return nil, t.err }
+
+func TestClient_Tracing_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+	tracer := NewInMemoryTracer()
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL+"/v1"), WithTracer(tracer))
+	if _, err := client.Organization.Get(context.Background(), "9"); !IsNotFoundError(err) {
+		t.Fatalf("error = %v, want not found", err)
+	}
+
+	transportErr := errors.New("connection refused")
+	failing := New(WithCredentials("k", "s"), WithTracer(tracer), WithHTTPClient(&http.Client{Transport: errTransport{transportErr}}))
+	if _, err := failing.Organization.Get(context.Background(), "9"); !errors.Is(err, transportErr) {
+		t.Fatalf("error = %v, want the transport error", err)
+	}
+
+	spans := tracer.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	if spans[0].Err == nil || spans[0].Attributes[AttrHTTPStatusCode] != http.StatusNotFound {
+		t.Errorf("404 span = %+v", spans[0])
+	}
+	if !errors.Is(spans[1].Err, transportErr) || spans[1].Attributes[AttrAttempts] != 1 {
+		t.Errorf("failed span = %+v", spans[1])
+	}
+	if spans[0].ParentID != "" || spans[0].TraceID == spans[1].TraceID {
+		t.Error("calls without a parent span should start separate traces")
+	}
+}
+
+func TestRandomHex(t *testing.T) {
+	// Every hex digit takes several values over many IDs: no bits are fixed,
+	// as they would be in IDs cut from a UUID
+	for _, n := range []int{8, 16} {
+		digits := make([]map[byte]bool, 2*n)
+		for i := range digits {
+			digits[i] = map[byte]bool{}
+		}
+		for i := 0; i < 200; i++ {
+			id := randomHex(n)
+			if len(id) != 2*n || strings.Trim(id, "0") == "" {
+				t.Fatalf("randomHex(%d) = %q", n, id)
+			}
+			for j := range digits {
+				digits[j][id[j]] = true
+			}
+		}
+		for j, seen := range digits {
+			if len(seen) < 4 {
+				t.Errorf("randomHex(%d): digit %d took only %d values", n, j, len(seen))
+			}
+		}
+	}
+}
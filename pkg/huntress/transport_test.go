@@ -0,0 +1,126 @@
+package huntress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/adapters/api"
+)
+
+func TestClient_AdaptersShareThePipeline(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if user, _, ok := r.BasicAuth(); !ok || user != "k" {
+			t.Errorf("expected credentials on %s", r.URL.Path)
+		}
+		switch {
+		case r.URL.Path == "/integrations/1" && n == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/integrations/1":
+			if _, err := w.Write([]byte(`{"id":"1"}`)); err != nil {
+				t.Errorf("error writing response: %v", err)
+			}
+		default:
+			w.Header().Set("X-Request-Id", "req-9")
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	logger := &recordingLogger{}
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithLogger(logger),
+		WithRetryConfig(1, time.Millisecond, time.Millisecond))
+	ctx := context.Background()
+
+	got, err := client.Integration.Get(ctx, "1")
+	if err != nil || got["id"] != "1" {
+		t.Fatalf("expected the retried integration, got %v, %v", got, err)
+	}
+	if len(logger.Entries("Retrying request")) != 1 {
+		t.Error("expected the adapter's retry to be logged by the shared pipeline")
+	}
+
+	_, err = client.AuditLog.Get(ctx, "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsNotFound() || apiErr.RequestID() != "req-9" {
+		t.Fatalf("expected a typed 404 from the audit log adapter, got %v", err)
+	}
+	if _, err := client.Bulk.BulkAgentAction(ctx, "isolate", []string{"1"}, nil); !IsNotFoundError(err) {
+		t.Errorf("expected a typed 404 from the bulk adapter, got %v", err)
+	}
+}
+
+func TestClient_ServicesShareOneLimiterAndRetrier(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path]++
+		first := seen[r.URL.Path] == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+	limiter := &countingLimiter{}
+	logger := &recordingLogger{}
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL), WithLogger(logger),
+		WithRateLimiter(limiter), WithRetryConfig(1, time.Millisecond, time.Millisecond))
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"service":  func() error { _, err := client.Organization.Get(ctx, "1"); return err },
+		"adapter":  func() error { _, err := client.Integration.Get(ctx, "1"); return err },
+		"audit":    func() error { _, err := client.AuditLog.Get(ctx, "1"); return err },
+		"download": func() error { _, err := client.Report.Download(ctx, "1", ""); return err },
+	}
+	for name, call := range calls {
+		if err := call(); err != nil {
+			t.Fatalf("%s call: %v", name, err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&limiter.waits); got != 2*int32(len(calls)) {
+		t.Errorf("limiter admitted %d requests, want %d: every attempt on every path", got, 2*len(calls))
+	}
+	if got := len(logger.Entries("Retrying request")); got != len(calls) {
+		t.Errorf("logged %d retries, want one per path", got)
+	}
+}
+
+func TestOrganizationRepository_UsesThePipeline(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); !ok || user != "k" {
+			t.Errorf("expected credentials on %s", r.URL.Path)
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"NOT_FOUND","message":"no such organization"}`))
+	}))
+	defer srv.Close()
+	limiter := &countingLimiter{}
+	client := New(WithCredentials("k", "s"), WithBaseURL(srv.URL),
+		WithRateLimiter(limiter), WithRetryConfig(1, time.Millisecond, time.Millisecond))
+	repo := api.NewOrganizationRepository(client.transport(), client.baseURL)
+
+	_, err := repo.Get(context.Background(), "1")
+	if !errors.Is(err, ErrOrgNotFound) {
+		t.Errorf("Get error = %v, want ErrOrgNotFound from the shared pipeline", err)
+	}
+	if got := atomic.LoadInt32(&limiter.waits); got != 2 {
+		t.Errorf("limiter admitted %d requests, want both attempts", got)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/url"
 	"reflect"
 	"strconv"
+
+	"github.com/greysquirr3l/bishoujo-huntress/internal/infrastructure/transport"
 )
 
 // Helper functions for service implementations
@@ -31,34 +33,13 @@ func extractPagination(resp *http.Response) *Pagination {
 	if resp == nil {
 		return nil
 	}
-
-	pagination := &Pagination{}
-
-	if currentPage := resp.Header.Get("X-Page"); currentPage != "" {
-		if val, err := parseInt(currentPage); err == nil {
-			pagination.CurrentPage = val
-		}
-	}
-
-	if perPage := resp.Header.Get("X-Per-Page"); perPage != "" {
-		if val, err := parseInt(perPage); err == nil {
-			pagination.PerPage = val
-		}
-	}
-
-	if totalPages := resp.Header.Get("X-Total-Pages"); totalPages != "" {
-		if val, err := parseInt(totalPages); err == nil {
-			pagination.TotalPages = val
-		}
+	info, _ := transport.PageInfoFromHeaders(resp.Header)
+	return &Pagination{
+		CurrentPage: info.Page,
+		PerPage:     info.PerPage,
+		TotalPages:  info.TotalPages,
+		TotalItems:  info.TotalItems,
 	}
-
-	if totalItems := resp.Header.Get("X-Total-Items"); totalItems != "" {
-		if val, err := parseInt(totalItems); err == nil {
-			pagination.TotalItems = val
-		}
-	}
-
-	return pagination
 }
 
 // parseInt parses a string to an integer, returning 0 if parsing fails